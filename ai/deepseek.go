@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/korjavin/lebentestbot/models"
@@ -16,6 +17,13 @@ import (
 const (
 	deepseekAPIURL = "https://api.deepseek.com/v1/chat/completions"
 	apiTimeoutSec  = 60 // Increased to 60 seconds to allow for more thorough responses
+
+	followUpMaxTokens  = 400 // Keeps follow-up answers short and cheap
+	translateMaxTokens = 600 // A question with four answers is short
+
+	// Deepseek chat prices in US dollars per million tokens
+	inputPricePerMillion  = 0.27
+	outputPricePerMillion = 1.10
 )
 
 // DeepseekClient manages interactions with Deepseek API
@@ -30,29 +38,43 @@ func NewDeepseekClient(apiKey string) *DeepseekClient {
 	}
 }
 
-type deepseekMessage struct {
+// Message is a single turn of a chat conversation with the model
+type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }
 
 type deepseekRequest struct {
-	Model    string            `json:"model"`
-	Messages []deepseekMessage `json:"messages"`
-	Timeout  int               `json:"timeout,omitempty"`
+	Model     string    `json:"model"`
+	Messages  []Message `json:"messages"`
+	Timeout   int       `json:"timeout,omitempty"`
+	MaxTokens int       `json:"max_tokens,omitempty"`
 }
 
 type deepseekResponseChoice struct {
-	Message deepseekMessage `json:"message"`
+	Message Message `json:"message"`
 }
 
 type deepseekResponse struct {
 	Choices []deepseekResponseChoice `json:"choices"`
 	ID      string                   `json:"id,omitempty"`
-	Usage   map[string]interface{}   `json:"usage,omitempty"`
+	Usage   Usage                    `json:"usage"`
+}
+
+// Usage reports the tokens consumed by a single API call
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Cost returns the estimated price of the call in US dollars
+func (u Usage) Cost() float64 {
+	return (float64(u.PromptTokens)*inputPricePerMillion + float64(u.CompletionTokens)*outputPricePerMillion) / 1e6
 }
 
 // AnalyzeQuestion uses Deepseek to analyze a question and provide insights
-func (c *DeepseekClient) AnalyzeQuestion(question *models.Question) (string, int, error) {
+func (c *DeepseekClient) AnalyzeQuestion(question *models.Question) (string, int, Usage, error) {
 	startTime := time.Now()
 	log.Printf("Starting analysis of question %d with Deepseek", question.Number)
 
@@ -69,24 +91,110 @@ Question: %s
 
 Answers: %v
 
-Please organize your response in clearly labeled sections and be concise. Answer in plain text.
+Please organize your response in clearly labeled sections and be concise. You may use simple Markdown (bold, lists, headings).
+`, question.Question, question.Answers)
+
+	content, usage, err := c.complete([]Message{
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}, 0)
+	if err != nil {
+		return content, -1, usage, err
+	}
+
+	// Attempt to determine the correct answer from the response
+	rightAnswer := extractRightAnswerFromContent(content, question)
+
+	totalDuration := time.Since(startTime)
+	log.Printf("Analysis of question %d completed in %v. Content length: %d",
+		question.Number, totalDuration, len(content))
+
+	return content, rightAnswer, usage, nil
+}
+
+// extractRightAnswerFromContent tries to determine the right answer index from the AI response
+// This is a very simplified implementation
+func extractRightAnswerFromContent(content string, question *models.Question) int {
+	// If there's already a known right answer, use it
+	if question.RightAnswer >= 0 && question.RightAnswer < len(question.Answers) {
+		return question.RightAnswer
+	}
+
+	// This is a placeholder for more sophisticated answer extraction logic
+	// In a real implementation, you would analyze the content to try to determine
+	// which answer the AI believes is correct
+
+	// For now, just returning -1 (unknown)
+	return -1
+}
+
+// FollowUp answers a learner's follow-up question about a test question.
+// The conversation is seeded with the question and, when available, the
+// explanation the learner has already seen; history holds the previous
+// follow-up turns, oldest first.
+func (c *DeepseekClient) FollowUp(question *models.Question, explanation string, history []Message, userMessage string) (string, Usage, error) {
+	log.Printf("Starting follow-up for question %d with Deepseek (%d previous turns)", question.Number, len(history))
+
+	systemPrompt := fmt.Sprintf(`
+You are a tutor helping a learner prepare for the German citizenship test ("Leben in Deutschland").
+The learner is working on the following question and will ask you follow-up questions about it.
+Answer briefly (a few sentences), in plain text, in the language the learner uses.
+Only discuss this question and the related facts about Germany.
+
+Question: %s
+
+Answers: %v
 `, question.Question, question.Answers)
 
+	if explanation != "" {
+		systemPrompt += "\nExplanation the learner has already seen:\n" + explanation + "\n"
+	}
+
+	messages := []Message{{Role: "system", Content: systemPrompt}}
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: userMessage})
+
+	return c.complete(messages, followUpMaxTokens)
+}
+
+// Translate translates a question and its answers into the language with the given
+// two-letter code, keeping the answers in their original order
+func (c *DeepseekClient) Translate(question *models.Question, language string) (string, Usage, error) {
+	log.Printf("Starting translation of question %d to %s with Deepseek", question.Number, language)
+
+	var answers strings.Builder
+	for i, answer := range question.Answers {
+		fmt.Fprintf(&answers, "%d. %s\n", i+1, answer)
+	}
+
+	prompt := fmt.Sprintf(`
+Translate the following question from the German citizenship test and its answers into the language with the ISO 639-1 code "%s".
+Keep the numbering of the answers. Reply with the translation only, in plain text, without explaining the right answer.
+
+Question: %s
+
+Answers:
+%s`, language, question.Question, answers.String())
+
+	return c.complete([]Message{{Role: "user", Content: prompt}}, translateMaxTokens)
+}
+
+// complete sends a chat completion request and returns the content of the first choice.
+// A maxTokens of 0 leaves the response length to the API default.
+func (c *DeepseekClient) complete(messages []Message, maxTokens int) (string, Usage, error) {
 	// Create request body
 	reqBody := deepseekRequest{
-		Model: "deepseek-chat",
-		Messages: []deepseekMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+		Model:     "deepseek-chat",
+		Messages:  messages,
+		MaxTokens: maxTokens,
 	}
 
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		log.Printf("Error marshaling request: %v", err)
-		return "", -1, err
+		return "", Usage{}, err
 	}
 
 	// Log the request payload (truncated for clarity)
@@ -105,7 +213,7 @@ Please organize your response in clearly labeled sections and be concise. Answer
 	req, err := http.NewRequestWithContext(ctx, "POST", deepseekAPIURL, bytes.NewBuffer(reqJSON))
 	if err != nil {
 		log.Printf("Error creating HTTP request: %v", err)
-		return "", -1, err
+		return "", Usage{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -124,10 +232,10 @@ Please organize your response in clearly labeled sections and be concise. Answer
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			log.Printf("Deepseek API request timed out after %v", reqDuration)
-			return "Sorry, the AI analysis timed out. Please try again later.", -1, err
+			return "Sorry, the AI analysis timed out. Please try again later.", Usage{}, err
 		}
 		log.Printf("Error sending request to Deepseek: %v after %v", err, reqDuration)
-		return "", -1, err
+		return "", Usage{}, err
 	}
 	defer resp.Body.Close()
 
@@ -137,13 +245,13 @@ Please organize your response in clearly labeled sections and be concise. Answer
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("Error reading response body: %v", err)
-		return "", -1, err
+		return "", Usage{}, err
 	}
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("API request failed with status %d: %s", resp.StatusCode, string(body))
-		return "", -1, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", Usage{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Log response (truncated for large responses)
@@ -158,37 +266,16 @@ Please organize your response in clearly labeled sections and be concise. Answer
 	var deepseekResp deepseekResponse
 	if err := json.Unmarshal(body, &deepseekResp); err != nil {
 		log.Printf("Error parsing Deepseek response: %v", err)
-		return "", -1, err
+		return "", Usage{}, err
 	}
 
 	if len(deepseekResp.Choices) == 0 {
 		log.Printf("No choices in API response")
-		return "", -1, fmt.Errorf("no choices in API response")
-	}
-
-	// Attempt to determine the correct answer from the response
-	content := deepseekResp.Choices[0].Message.Content
-	rightAnswer := extractRightAnswerFromContent(content, question)
-
-	totalDuration := time.Since(startTime)
-	log.Printf("Analysis of question %d completed in %v. Content length: %d",
-		question.Number, totalDuration, len(content))
-
-	return content, rightAnswer, nil
-}
-
-// extractRightAnswerFromContent tries to determine the right answer index from the AI response
-// This is a very simplified implementation
-func extractRightAnswerFromContent(content string, question *models.Question) int {
-	// If there's already a known right answer, use it
-	if question.RightAnswer >= 0 && question.RightAnswer < len(question.Answers) {
-		return question.RightAnswer
+		return "", deepseekResp.Usage, fmt.Errorf("no choices in API response")
 	}
 
-	// This is a placeholder for more sophisticated answer extraction logic
-	// In a real implementation, you would analyze the content to try to determine
-	// which answer the AI believes is correct
+	log.Printf("Deepseek usage: %d prompt tokens, %d completion tokens",
+		deepseekResp.Usage.PromptTokens, deepseekResp.Usage.CompletionTokens)
 
-	// For now, just returning -1 (unknown)
-	return -1
+	return deepseekResp.Choices[0].Message.Content, deepseekResp.Usage, nil
 }
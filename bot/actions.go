@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	translateCallbackPrefix = "translate:"
+	knownCallbackPrefix     = "known:"
+
+	defaultLanguage = "en"
+)
+
+// callbackQuestion returns the question whose number follows prefix in the callback data.
+// Invalid or outdated buttons are acknowledged and return nil.
+func (b *Bot) callbackQuestion(callback *tgbotapi.CallbackQuery, prefix string) *models.Question {
+	questionNum, err := strconv.Atoi(strings.TrimPrefix(callback.Data, prefix))
+	if err != nil {
+		log.Printf("Invalid question number in callback: %s", callback.Data)
+		b.sendCallbackResponse(callback.ID, "")
+		return nil
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil || callback.Message == nil {
+		b.sendCallbackResponse(callback.ID, "Sorry, this question is no longer available.")
+		return nil
+	}
+	return question
+}
+
+// userLanguage returns the two-letter code of the language the user wants translations in
+func (b *Bot) userLanguage(userID int64) string {
+	user, err := b.db.GetUser(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %v", userID, err)
+	}
+	if user == nil {
+		return defaultLanguage
+	}
+	if user.Language != "" {
+		return user.Language
+	}
+	// Telegram reports codes such as "en" or "pt-br"
+	if code := strings.ToLower(user.LanguageCode); len(code) >= 2 && languagePattern.MatchString(code[:2]) {
+		return code[:2]
+	}
+	return defaultLanguage
+}
+
+// handleTranslateCallback sends a translation of the question into the user's language
+func (b *Bot) handleTranslateCallback(callback *tgbotapi.CallbackQuery) {
+	question := b.callbackQuestion(callback, translateCallbackPrefix)
+	if question == nil {
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
+	userID := callback.From.ID
+	language := b.userLanguage(userID)
+
+	if language == "de" {
+		b.sendCallbackResponse(callback.ID, "The question is already in German. Choose another language with /settings language <code>.")
+		return
+	}
+
+	translation, err := b.db.GetTranslation(question.Number, language)
+	if err != nil {
+		log.Printf("Error retrieving translation: %v", err)
+	}
+	if translation != "" {
+		b.sendCallbackResponse(callback.ID, "")
+		b.sendTranslation(chatID, question, language, translation)
+		return
+	}
+
+	if ok, quotaMessage := b.checkAIQuota(userID); !ok {
+		b.sendCallbackResponse(callback.ID, "")
+		b.sendMessage(chatID, quotaMessage)
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "Translating, please wait a moment...")
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in translation goroutine: %v", r)
+			}
+		}()
+
+		translation, usage, err := b.deepseek.Translate(question, language)
+		b.recordAIUsage(userID, question.Number, aiKindTranslate, usage)
+		if err != nil {
+			log.Printf("Error translating question %d to %s: %v", question.Number, language, err)
+			b.sendMessage(chatID, "Sorry, I couldn't translate this question. Please try again later.")
+			return
+		}
+
+		if err := b.db.SaveTranslation(question.Number, language, translation); err != nil {
+			log.Printf("Error caching translation: %v", err)
+		}
+
+		b.sendTranslation(chatID, question, language, translation)
+	}()
+}
+
+// sendTranslation sends the translation of a question
+func (b *Bot) sendTranslation(chatID int64, question *models.Question, language, translation string) {
+	b.sendMessage(chatID, "🌐 Question #"+strconv.Itoa(question.Number)+" in "+strings.ToUpper(language)+":\n\n"+translation)
+}
+
+// handleKnownCallback leaves the question out of the user's practice from now on
+func (b *Bot) handleKnownCallback(callback *tgbotapi.CallbackQuery) {
+	question := b.callbackQuestion(callback, knownCallbackPrefix)
+	if question == nil {
+		return
+	}
+
+	if err := b.db.MarkQuestionKnown(callback.From.ID, question.Number); err != nil {
+		log.Printf("Error marking question %d as known: %v", question.Number, err)
+		b.sendCallbackResponse(callback.ID, "Sorry, I couldn't save this. Please try again later.")
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "Marked as known, I won't ask it again. Use /settings known reset to practise all questions again.")
+}
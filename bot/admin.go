@@ -0,0 +1,303 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const aiKindRegen = "regen"
+
+const adminHelpText = `Admin commands:
+/admin stats - Active users, answers today and cache hit rate
+/admin usage - AI spend per day and per user
+/admin broadcast <text> - Send a message to all users
+/admin broadcast status [id] - Show broadcast progress
+/admin broadcast pause|resume|cancel <id> - Control a broadcast
+/admin regen <question> - Regenerate the cached explanation of a question
+/admin user <id> - Show a learner's summary
+/admin reports - List open problem reports
+/admin report <id> - Review a report
+/admin resolve <id> - Close a report
+/admin answer <question> <number> - Override the right answer of a question
+/admin explain <question> <text> - Override the explanation of a question
+/admin reload - Reload the question catalogue`
+
+// isAdmin reports whether the user is configured as an operator
+func (b *Bot) isAdmin(userID int64) bool {
+	for _, id := range b.cfg.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// recordCacheLookup counts an explanation lookup as a cache hit or miss
+func (b *Bot) recordCacheLookup(hit bool) {
+	if err := b.db.RecordCacheLookup(hit); err != nil {
+		log.Printf("Error recording cache lookup: %v", err)
+	}
+}
+
+// handleAdminCommand dispatches the /admin subcommands. The caller must have checked isAdmin.
+func (b *Bot) handleAdminCommand(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)
+	if len(args) < 2 {
+		b.sendMessage(message.Chat.ID, adminHelpText)
+		return
+	}
+
+	log.Printf("Admin %d issued command: %s", message.From.ID, message.Text)
+
+	switch args[1] {
+	case "stats":
+		b.handleAdminStats(message)
+	case "usage":
+		b.handleAdminUsage(message)
+	case "broadcast":
+		b.handleAdminBroadcast(message)
+	case "regen":
+		b.handleAdminRegen(message, args[2:])
+	case "user":
+		b.handleAdminUser(message, args[2:])
+	case "reports":
+		b.handleAdminReports(message)
+	case "report":
+		b.handleAdminReport(message, args[2:])
+	case "resolve":
+		b.handleAdminResolve(message, args[2:])
+	case "answer":
+		b.handleAdminAnswer(message, args[2:])
+	case "explain":
+		b.handleAdminExplain(message, args[2:])
+	case "reload":
+		b.handleAdminReload(message)
+	default:
+		b.sendMessage(message.Chat.ID, adminHelpText)
+	}
+}
+
+// handleAdminStats reports active users, answers today and the explanation cache hit rate
+func (b *Bot) handleAdminStats(message *tgbotapi.Message) {
+	now := time.Now()
+	today := startOfDay(now)
+	weekAgo := startOfDay(now.AddDate(0, 0, -6))
+
+	totalUsers, err := b.db.CountUsers()
+	if err != nil {
+		log.Printf("Error counting users: %v", err)
+	}
+	activeToday, err := b.db.CountActiveUsersSince(today)
+	if err != nil {
+		log.Printf("Error counting active users: %v", err)
+	}
+	activeWeek, err := b.db.CountActiveUsersSince(weekAgo)
+	if err != nil {
+		log.Printf("Error counting active users: %v", err)
+	}
+	answersToday, err := b.db.CountAnswersSince(today)
+	if err != nil {
+		log.Printf("Error counting answers: %v", err)
+	}
+	hitsToday, missesToday, err := b.db.GetCacheStatsSince(now.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Error getting cache stats: %v", err)
+	}
+	hitsWeek, missesWeek, err := b.db.GetCacheStatsSince(now.AddDate(0, 0, -6).Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Error getting cache stats: %v", err)
+	}
+
+	statMessage := fmt.Sprintf(`📈 Bot Statistics:
+
+Users: %d
+Active today: %d
+Active in the last 7 days: %d
+Answers today: %d
+
+Explanation cache hit rate:
+Today: %s
+Last 7 days: %s`,
+		totalUsers, activeToday, activeWeek, answersToday,
+		formatHitRate(hitsToday, missesToday), formatHitRate(hitsWeek, missesWeek))
+
+	b.sendMessage(message.Chat.ID, statMessage)
+}
+
+// formatHitRate formats cache hits and misses as a percentage
+func formatHitRate(hits, misses int) string {
+	if hits+misses == 0 {
+		return "no lookups"
+	}
+	return fmt.Sprintf("%.1f%% (%d of %d)", float64(hits)/float64(hits+misses)*100, hits, hits+misses)
+}
+
+// handleAdminUsage reports AI spend per day and per user
+func (b *Bot) handleAdminUsage(message *tgbotapi.Message) {
+	report, err := UsageReport(b.db, time.Now())
+	if err != nil {
+		log.Printf("Error getting AI usage: %v", err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't retrieve the usage report.")
+		return
+	}
+
+	report += fmt.Sprintf("\n\nDaily limits: %d calls per user, %d calls overall (0 means unlimited)",
+		b.cfg.AIUserDailyLimit, b.cfg.AIGlobalDailyLimit)
+
+	b.sendMessage(message.Chat.ID, report)
+}
+
+// handleAdminRegen regenerates the cached explanation of a question
+func (b *Bot) handleAdminRegen(message *tgbotapi.Message, args []string) {
+	if len(args) != 1 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin regen <question>")
+		return
+	}
+
+	questionNum, err := strconv.Atoi(args[0])
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The question number must be a number.")
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Question #%d does not exist.", questionNum))
+		return
+	}
+
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("Regenerating the explanation of question #%d...", questionNum))
+
+	adminID := message.From.ID
+	chatID := message.Chat.ID
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in regen goroutine: %v", r)
+			}
+		}()
+
+		response, rightAnswer, usage, err := b.deepseek.AnalyzeQuestion(question)
+		b.recordAIUsage(adminID, questionNum, aiKindRegen, usage)
+		if err != nil {
+			log.Printf("Error regenerating explanation for question %d: %v", questionNum, err)
+			b.sendMessage(chatID, fmt.Sprintf("Sorry, I couldn't regenerate question #%d: %v", questionNum, err))
+			return
+		}
+
+		if err := b.cacheResponse(questionNum, response, rightAnswer); err != nil {
+			log.Printf("Error caching regenerated response: %v", err)
+			b.sendMessage(chatID, "Sorry, I couldn't save the regenerated explanation.")
+			return
+		}
+
+		b.sendMessage(chatID, fmt.Sprintf("New explanation of question #%d:\n\n%s", questionNum, response))
+	}()
+}
+
+// handleAdminUser shows a summary of a learner's progress and AI usage
+func (b *Bot) handleAdminUser(message *tgbotapi.Message, args []string) {
+	if len(args) != 1 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin user <id>")
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The user ID must be a number.")
+		return
+	}
+
+	correct, incorrect, err := b.db.GetUserStats(userID)
+	if err != nil {
+		log.Printf("Error getting stats for user %d: %v", userID, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't retrieve this user's statistics.")
+		return
+	}
+
+	first, last, err := b.db.GetUserActivityRange(userID)
+	if err != nil {
+		log.Printf("Error getting activity range for user %d: %v", userID, err)
+	}
+
+	usageToday, err := b.db.GetUserAIUsageSince(userID, startOfDay(time.Now()))
+	if err != nil {
+		log.Printf("Error getting AI usage for user %d: %v", userID, err)
+	}
+	usageTotal, err := b.db.GetUserAIUsageSince(userID, 0)
+	if err != nil {
+		log.Printf("Error getting AI usage for user %d: %v", userID, err)
+	}
+
+	user, err := b.db.GetUser(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %v", userID, err)
+	}
+
+	total := correct + incorrect
+	var accuracy float64
+	if total > 0 {
+		accuracy = float64(correct) / float64(total) * 100
+	}
+
+	summary := fmt.Sprintf("👤 User %d\n", userID)
+	if user != nil {
+		status := "active"
+		if !user.Active {
+			status = "inactive since " + formatTimestamp(user.InactiveSince)
+		}
+		summary += fmt.Sprintf(`
+Name: %s
+Username: %s
+Language: %s
+Federal state: %s
+First seen: %s
+Last seen: %s
+Status: %s
+`,
+			strings.TrimSpace(user.FirstName+" "+user.LastName), orDefault(user.Username, "none"),
+			orDefault(user.Language, orDefault(user.LanguageCode, "unknown")), orDefault(user.Bundesland, "not set"),
+			formatTimestamp(user.FirstSeen), formatTimestamp(user.LastSeen), status)
+	}
+
+	summary += fmt.Sprintf(`
+Answers: %d (%d correct, %d incorrect)
+Accuracy: %.1f%%
+First answer: %s
+Last answer: %s
+
+AI calls today: %d ($%.4f)
+AI calls in total: %d ($%.4f)`,
+		total, correct, incorrect, accuracy,
+		formatTimestamp(first), formatTimestamp(last),
+		usageToday.Calls, usageToday.Cost, usageTotal.Calls, usageTotal.Cost)
+
+	if total > 0 {
+		incorrectQuestions, err := b.db.GetMostFrequentIncorrectQuestions(userID, 5)
+		if err != nil {
+			log.Printf("Error getting incorrect questions for user %d: %v", userID, err)
+		}
+		if len(incorrectQuestions) > 0 {
+			numbers := make([]string, len(incorrectQuestions))
+			for i, q := range incorrectQuestions {
+				numbers[i] = strconv.Itoa(q.QuestionNumber)
+			}
+			summary += "\n\nMost missed questions: " + strings.Join(numbers, ", ")
+		}
+	}
+
+	b.sendMessage(message.Chat.ID, summary)
+}
+
+// formatTimestamp formats a Unix time for admin reports
+func formatTimestamp(ts int64) string {
+	if ts == 0 {
+		return "never"
+	}
+	return time.Unix(ts, 0).Format("2006-01-02 15:04")
+}
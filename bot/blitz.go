@@ -0,0 +1,309 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	blitzCallbackPrefix = "blitz:"
+	blitzLength         = 10
+	blitzQuestionTime   = 30 * time.Second
+)
+
+// blitzSession is a running /blitz round of a user
+type blitzSession struct {
+	id        int64
+	userID    int64
+	chatID    int64
+	questions []*models.Question
+	answers   []int // Right answer of each question
+	current   int   // Index of the question being asked
+	waiting   bool  // Whether the current question still accepts an answer
+	messageID int   // Message of the current question
+	correct   int
+	timedOut  int
+}
+
+// blitzSessions tracks the running /blitz round of every user
+type blitzSessions struct {
+	mu     sync.Mutex
+	nextID int64
+	byUser map[int64]*blitzSession // Keyed by user ID
+}
+
+// handleBlitzCommand handles the /blitz command, starting a timed round of questions.
+// "/blitz stop" ends the running round.
+func (b *Bot) handleBlitzCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+
+	if strings.TrimSpace(message.CommandArguments()) == "stop" {
+		b.blitzSessions.mu.Lock()
+		session, running := b.blitzSessions.byUser[userID]
+		var asked int
+		if running {
+			delete(b.blitzSessions.byUser, userID)
+			asked = session.current
+		}
+		b.blitzSessions.mu.Unlock()
+
+		if !running {
+			b.sendMessage(chatID, "You have no blitz round running. Start one with /blitz.")
+			return
+		}
+		b.timers.Cancel(blitzTimerKey(userID))
+		b.sendMessage(chatID, fmt.Sprintf("Blitz round stopped after %d of %d questions.", asked, len(session.questions)))
+		return
+	}
+
+	// Every answer has to be graded right away, so only questions with a known answer are used
+	candidates := b.questionsWithKnownAnswers()
+	if len(candidates) == 0 {
+		b.sendMessage(chatID, "The blitz round needs questions whose right answer is known, and there are none yet. Practise with /next first!")
+		return
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > blitzLength {
+		candidates = candidates[:blitzLength]
+	}
+
+	b.blitzSessions.mu.Lock()
+	if _, running := b.blitzSessions.byUser[userID]; running {
+		b.blitzSessions.mu.Unlock()
+		b.sendMessage(chatID, "Your blitz round is already running! Use /blitz stop to end it.")
+		return
+	}
+	b.blitzSessions.nextID++
+	session := &blitzSession{
+		id:        b.blitzSessions.nextID,
+		userID:    userID,
+		chatID:    chatID,
+		questions: candidates,
+	}
+	b.blitzSessions.byUser[userID] = session
+	b.blitzSessions.mu.Unlock()
+
+	for _, question := range session.questions {
+		session.answers = append(session.answers, b.knownRightAnswer(question))
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("⚡ Blitz round: %d questions, %d seconds each. Go!",
+		len(session.questions), int(blitzQuestionTime.Seconds())))
+	log.Printf("Started blitz round %d for user %d with %d questions", session.id, userID, len(session.questions))
+
+	b.askBlitzQuestion(session)
+}
+
+// askBlitzQuestion sends the current question of a round and starts its countdown
+func (b *Bot) askBlitzQuestion(session *blitzSession) {
+	b.blitzSessions.mu.Lock()
+	index := session.current
+	question := session.questions[index]
+	session.waiting = true
+	b.blitzSessions.mu.Unlock()
+
+	text := fmt.Sprintf("⚡ %d/%d · ⏱ %d s\n\n<b>Question #%d:</b> %s",
+		index+1, len(session.questions), int(blitzQuestionTime.Seconds()), question.Number, escapeHTML(question.Question))
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, answer := range question.Answers {
+		callbackData := fmt.Sprintf("%s%d:%d:%d", blitzCallbackPrefix, session.id, index, i)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(answer, callbackData)))
+	}
+
+	// The countdown starts before the question is sent: an answer that arrives while
+	// Send is still waiting would otherwise ask the next question, whose countdown this
+	// one would then replace
+	b.timers.Schedule(blitzTimerKey(session.userID), blitzQuestionTime, func() {
+		b.blitzTimeout(session, index)
+	})
+
+	msg := tgbotapi.NewMessage(session.chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		log.Printf("Error sending blitz question to chat %d: %v", session.chatID, err)
+	}
+
+	// The round may have moved on to the next question in the meantime
+	b.blitzSessions.mu.Lock()
+	if session.current == index {
+		session.messageID = sent.MessageID
+	}
+	b.blitzSessions.mu.Unlock()
+}
+
+// handleBlitzCallback grades an answer to a blitz question and moves on to the next one
+func (b *Bot) handleBlitzCallback(callback *tgbotapi.CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(callback.Data, blitzCallbackPrefix), ":")
+	if len(parts) != 3 {
+		log.Printf("Invalid blitz callback format: %s", callback.Data)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		log.Printf("Invalid session ID in blitz callback: %v", err)
+		return
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Printf("Invalid question index in blitz callback: %v", err)
+		return
+	}
+	answerNum, err := strconv.Atoi(parts[2])
+	if err != nil {
+		log.Printf("Invalid answer number in blitz callback: %v", err)
+		return
+	}
+
+	b.blitzSessions.mu.Lock()
+	session, running := b.blitzSessions.byUser[callback.From.ID]
+	if !running || session.id != sessionID || session.current != index || !session.waiting {
+		b.blitzSessions.mu.Unlock()
+		b.sendCallbackResponse(callback.ID, "Too late, this question is already closed.")
+		return
+	}
+	session.waiting = false
+	rightAnswer := session.answers[index]
+	isCorrect := answerNum == rightAnswer
+	if isCorrect {
+		session.correct++
+	}
+	messageID := session.messageID
+	b.blitzSessions.mu.Unlock()
+
+	if callback.Message != nil {
+		messageID = callback.Message.MessageID
+	}
+
+	// Answered in time, so the countdown must not fire anymore
+	b.timers.Cancel(blitzTimerKey(session.userID))
+
+	question := session.questions[index]
+	if err := b.db.SaveUserActivity(session.userID, question.Number, answerNum, isCorrect); err != nil {
+		log.Printf("Error saving user activity: %v", err)
+	}
+
+	if isCorrect {
+		b.sendCallbackResponse(callback.ID, "✅ Correct!")
+	} else {
+		b.sendCallbackResponse(callback.ID, "❌ Wrong")
+	}
+	b.closeBlitzQuestion(session, messageID, index, blitzResultText(question, answerNum, rightAnswer))
+	b.nextBlitzQuestion(session)
+}
+
+// blitzTimeout marks the question at index as unanswered when its countdown runs out
+func (b *Bot) blitzTimeout(session *blitzSession, index int) {
+	b.blitzSessions.mu.Lock()
+	if b.blitzSessions.byUser[session.userID] != session || session.current != index || !session.waiting {
+		b.blitzSessions.mu.Unlock()
+		return
+	}
+	session.waiting = false
+	session.timedOut++
+	rightAnswer := session.answers[index]
+	messageID := session.messageID
+	b.blitzSessions.mu.Unlock()
+
+	question := session.questions[index]
+	text := "⌛ Time's up!"
+	if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
+		text += " The right answer is: " + escapeHTML(question.Answers[rightAnswer])
+	}
+	b.closeBlitzQuestion(session, messageID, index, text)
+	b.nextBlitzQuestion(session)
+}
+
+// closeBlitzQuestion replaces the answer buttons of a blitz question with its result
+func (b *Bot) closeBlitzQuestion(session *blitzSession, messageID, index int, result string) {
+	if messageID == 0 {
+		return
+	}
+	question := session.questions[index]
+	text := fmt.Sprintf("⚡ %d/%d\n\n<b>Question #%d:</b> %s\n\n%s",
+		index+1, len(session.questions), question.Number, escapeHTML(question.Question), result)
+
+	edit := tgbotapi.NewEditMessageText(session.chatID, messageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error editing blitz question: %v", err)
+	}
+}
+
+// nextBlitzQuestion asks the next question of the round, or finishes the round after the last one
+func (b *Bot) nextBlitzQuestion(session *blitzSession) {
+	b.blitzSessions.mu.Lock()
+	if b.blitzSessions.byUser[session.userID] != session {
+		// The round was stopped
+		b.blitzSessions.mu.Unlock()
+		return
+	}
+	session.current++
+	finished := session.current >= len(session.questions)
+	if finished {
+		delete(b.blitzSessions.byUser, session.userID)
+	}
+	b.blitzSessions.mu.Unlock()
+
+	if finished {
+		b.finishBlitz(session)
+		return
+	}
+	b.askBlitzQuestion(session)
+}
+
+// finishBlitz reports the final score of a round and updates the personal best
+func (b *Bot) finishBlitz(session *blitzSession) {
+	total := len(session.questions)
+	summary := fmt.Sprintf("🏁 Blitz round finished!\n\nScore: %d of %d right", session.correct, total)
+	if session.timedOut > 0 {
+		summary += fmt.Sprintf(" (%d timed out)", session.timedOut)
+	}
+
+	previous, newBest, err := b.db.RecordBlitzRound(session.userID, session.correct, total)
+	switch {
+	case err != nil:
+		log.Printf("Error recording blitz round of user %d: %v", session.userID, err)
+	case previous == nil:
+		summary += "\n\n🏆 That's your first personal best!"
+	case newBest:
+		summary += fmt.Sprintf("\n\n🏆 New personal best! Your previous best was %d of %d.", previous.Score, previous.Questions)
+	default:
+		summary += fmt.Sprintf("\n\nYour personal best is %d of %d.", previous.Score, previous.Questions)
+	}
+
+	summary += "\n\nUse /blitz to play again."
+	b.sendMessage(session.chatID, summary)
+	log.Printf("Finished blitz round %d for user %d: %d of %d", session.id, session.userID, session.correct, total)
+}
+
+// blitzResultText describes the result of an answer to a blitz question
+func blitzResultText(question *models.Question, answerNum, rightAnswer int) string {
+	if answerNum == rightAnswer {
+		return "✅ Correct: " + escapeHTML(question.Answers[rightAnswer])
+	}
+	text := "❌ Wrong."
+	if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
+		text += " The right answer is: " + escapeHTML(question.Answers[rightAnswer])
+	}
+	return text
+}
+
+// blitzTimerKey returns the scheduler key of a user's blitz countdown
+func blitzTimerKey(userID int64) string {
+	return "blitz:" + strconv.FormatInt(userID, 10)
+}
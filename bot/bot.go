@@ -1,31 +1,42 @@
 package bot
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/korjavin/lebentestbot/ai"
+	"github.com/korjavin/lebentestbot/catalogue"
 	"github.com/korjavin/lebentestbot/config"
 	"github.com/korjavin/lebentestbot/database"
 	"github.com/korjavin/lebentestbot/models"
+	"github.com/korjavin/lebentestbot/search"
 )
 
 // Bot represents the Telegram bot
 type Bot struct {
-	api           *tgbotapi.BotAPI
-	db            *database.DB
-	deepseek      *ai.DeepseekClient
-	questions     []models.Question
-	userQuestions map[int64]int               // Maps user IDs to their current question number
-	recentlyAsked map[int64]map[int]time.Time // Tracks recently asked questions per user
+	cfg            *config.Config
+	api            *tgbotapi.BotAPI
+	db             *database.DB
+	deepseek       *ai.DeepseekClient
+	catalogue      *catalogue.Catalogue
+	searchIndex    *search.Index
+	userQuestions  map[int64]int               // Maps user IDs to their current question number
+	recentlyAsked  map[int64]map[int]time.Time // Tracks recently asked questions per user
+	conversations  map[int64]*conversation     // Follow-up conversations about the current question
+	broadcastWake  chan struct{}               // Wakes the broadcast worker when a broadcast is queued or resumed
+	groupQuizzes   groupQuizzes                // Running quiz questions in group chats
+	polls          map[string]pollInfo         // Questions presented as polls, keyed by poll ID
+	timers         *scheduler                  // Countdowns of timed questions
+	blitzSessions  blitzSessions               // Running /blitz rounds
+	callbacks      map[string]callbackHandler  // Inline button handlers, keyed by callback route
+	reportComments map[int64]int64             // Reports waiting for a comment, keyed by user ID
 }
 
 const (
@@ -34,7 +45,26 @@ const (
 	cmdHelp  = "help"
 	cmdStat  = "stat"
 
-	callbackPrefix = "answer:"
+	cmdSearch   = "search"
+	cmdQuestion = "q"
+	cmdLearn    = "learn"
+
+	cmdFlashcards = "flashcards"
+	cmdBlitz      = "blitz"
+
+	cmdSettings = "settings"
+	cmdMyData   = "mydata"
+	cmdForgetMe = "forgetme"
+
+	cmdQuiz        = "quiz"
+	cmdLeaderboard = "leaderboard"
+
+	cmdAdmin = "admin"
+
+	unknownCommandText = "Unknown command. Use /start to begin, /next for a new question, or /help for assistance."
+
+	callbackPrefix       = "ans:"    // Followed by the presentation ID and the answer index
+	legacyCallbackPrefix = "answer:" // Followed by the question number and the answer index
 )
 
 // New creates a new bot instance
@@ -55,48 +85,128 @@ func New(cfg *config.Config) (*Bot, error) {
 	}
 
 	// Load questions
-	questions, err := loadQuestions()
+	questionBank, warnings, err := catalogue.Load(cfg.AssetsDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load questions: %w", err)
 	}
+	questions := questionBank.Questions()
 
-	log.Printf("Loaded %d questions", len(questions))
+	log.Printf("Loaded %d questions of version %q from %s", len(questions), questionBank.Version(), questionBank.Path())
+	logCatalogueWarnings(warnings)
 
-	return &Bot{
-		api:           botAPI,
-		db:            db,
-		deepseek:      ai.NewDeepseekClient(cfg.DeepseekAPIKey),
-		questions:     questions,
-		userQuestions: make(map[int64]int),
-		recentlyAsked: make(map[int64]map[int]time.Time),
-	}, nil
+	// The catalogue may have been replaced while the bot was offline
+	migrateCatalogue(db, questionBank)
+
+	// Cached explanations contain translations, so they are searchable too
+	cachedResponses, err := db.GetCachedResponses()
+	if err != nil {
+		log.Printf("Error loading cached responses for the search index: %v", err)
+	}
+
+	regradeActivities(db, questions)
+
+	b := &Bot{
+		cfg:            cfg,
+		api:            botAPI,
+		db:             db,
+		deepseek:       ai.NewDeepseekClient(cfg.DeepseekAPIKey),
+		catalogue:      questionBank,
+		searchIndex:    search.NewIndex(questions, cachedResponses),
+		userQuestions:  make(map[int64]int),
+		recentlyAsked:  make(map[int64]map[int]time.Time),
+		conversations:  make(map[int64]*conversation),
+		broadcastWake:  make(chan struct{}, 1),
+		groupQuizzes:   groupQuizzes{byGroup: make(map[int64]*groupQuiz)},
+		polls:          make(map[string]pollInfo),
+		timers:         newScheduler(),
+		blitzSessions:  blitzSessions{byUser: make(map[int64]*blitzSession)},
+		reportComments: make(map[int64]int64),
+	}
+	b.callbacks = b.callbackHandlers()
+
+	return b, nil
 }
 
-// loadQuestions loads questions from the JSON file
-func loadQuestions() ([]models.Question, error) {
-	file, err := os.ReadFile("assets/questions.json")
+// regradeActivities brings the grades of all recorded answers in line with the right
+// answers known now, e.g. after the catalogue was corrected while the bot was offline
+func regradeActivities(db *database.DB, questions []models.Question) {
+	knownAnswers, err := db.GetKnownRightAnswers()
 	if err != nil {
-		return nil, err
+		log.Printf("Error getting known right answers for regrading: %v", err)
+		return
 	}
 
-	var questions []models.Question
-	if err := json.Unmarshal(file, &questions); err != nil {
-		return nil, err
+	var regraded int64
+	for _, q := range questions {
+		rightAnswer, ok := knownAnswers[q.Number]
+		if !ok {
+			rightAnswer = q.RightAnswer
+		}
+		if rightAnswer == -1 {
+			continue
+		}
+
+		changed, err := db.RegradeActivities(q.Number, rightAnswer)
+		if err != nil {
+			log.Printf("Error regrading answers to question %d: %v", q.Number, err)
+			continue
+		}
+		regraded += changed
 	}
 
-	// Filter out questions with Number -1 as they might be broken
-	var validQuestions []models.Question
-	for _, q := range questions {
-		if q.Number != -1 {
-			validQuestions = append(validQuestions, q)
+	if regraded > 0 {
+		log.Printf("Regraded %d answers to match the known right answers", regraded)
+	}
+}
+
+// findQuestion returns the question with the given number, or nil if there is none
+func (b *Bot) findQuestion(number int) *models.Question {
+	questions := b.questions()
+	for i := range questions {
+		if questions[i].Number == number {
+			return &questions[i]
+		}
+	}
+	return nil
+}
+
+// knownRightAnswer returns the index of the right answer from the AI cache or
+// the catalogue, or -1 if it is not known yet
+func (b *Bot) knownRightAnswer(question *models.Question) int {
+	_, cachedRightAnswer, err := b.db.GetCachedDeepseekResponse(question.Number)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	} else if cachedRightAnswer != -1 {
+		return cachedRightAnswer
+	}
+	return question.RightAnswer
+}
+
+// cacheResponse stores an AI explanation and makes it searchable
+func (b *Bot) cacheResponse(questionNum int, response string, rightAnswer int) error {
+	// Don't lose a right answer we already know, e.g. one set by an admin,
+	// if a new analysis couldn't determine it
+	if rightAnswer == -1 {
+		if _, cachedRightAnswer, err := b.db.GetCachedDeepseekResponse(questionNum); err == nil {
+			rightAnswer = cachedRightAnswer
 		}
 	}
 
-	return validQuestions, nil
+	if err := b.db.CacheDeepseekResponse(questionNum, response, rightAnswer); err != nil {
+		return err
+	}
+	b.searchIndex.SetExtraText(questionNum, response)
+	return nil
 }
 
 // Start starts the bot and listens for updates
 func (b *Bot) Start() {
+	// Deliver queued broadcasts in the background
+	go b.runBroadcasts()
+
+	// Pick up changes to the question catalogue without a restart
+	go b.watchCatalogue()
+
 	log.Println("Starting bot polling...")
 
 	u := tgbotapi.NewUpdate(0)
@@ -105,8 +215,14 @@ func (b *Bot) Start() {
 	updates := b.api.GetUpdatesChan(u)
 
 	for update := range updates {
+		b.touchUser(&update)
+
 		if update.CallbackQuery != nil {
 			b.handleCallback(update.CallbackQuery)
+		} else if update.InlineQuery != nil {
+			b.handleInlineQuery(update.InlineQuery)
+		} else if update.PollAnswer != nil {
+			b.handlePollAnswer(update.PollAnswer)
 		} else if update.Message != nil {
 			b.handleMessage(update.Message)
 		}
@@ -118,6 +234,11 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 	userID := message.From.ID
 	log.Printf("Received message from %s (ID: %d): %s", message.From.UserName, userID, message.Text)
 
+	// A command instead of a comment means the user doesn't want to comment on their report
+	if strings.HasPrefix(message.Text, "/") {
+		delete(b.reportComments, userID)
+	}
+
 	switch {
 	case strings.HasPrefix(message.Text, "/"+cmdStart):
 		b.handleStartCommand(message)
@@ -127,9 +248,47 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		b.handleHelpCommand(message)
 	case strings.HasPrefix(message.Text, "/"+cmdStat):
 		b.handleStatCommand(message)
-	default:
+	case strings.HasPrefix(message.Text, "/"+cmdSearch):
+		b.handleSearchCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdLearn):
+		b.handleLearnCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdFlashcards):
+		b.handleFlashcardsCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdBlitz):
+		b.handleBlitzCommand(message)
+	case message.Command() == cmdQuestion:
+		// Matched exactly, as "/q" is also a prefix of "/quiz"
+		b.handleQuestionCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdQuiz):
+		b.handleQuizCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdLeaderboard):
+		b.handleLeaderboardCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdSettings):
+		b.handleSettingsCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdMyData):
+		b.handleMyDataCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdForgetMe):
+		b.handleForgetMeCommand(message)
+	case strings.HasPrefix(message.Text, "/"+cmdAdmin):
+		// Operator commands are only available to configured admins; everyone else
+		// gets the same reply as for any unknown command
+		if !b.isAdmin(userID) {
+			log.Printf("Rejected admin command from user %d", userID)
+			b.sendMessage(message.Chat.ID, unknownCommandText)
+			return
+		}
+		b.handleAdminCommand(message)
+	case !message.Chat.IsPrivate():
+		// In groups, ignore other messages and commands meant for other bots
+		return
+	case strings.HasPrefix(message.Text, "/") || strings.TrimSpace(message.Text) == "":
 		// Send a help message for unknown commands
-		b.sendMessage(message.Chat.ID, "Unknown command. Use /start to begin, /next for a new question, or /help for assistance.")
+		b.sendMessage(message.Chat.ID, unknownCommandText)
+	case b.handleReportComment(message):
+		// The text was the comment on a report the user just made
+	default:
+		// Free text is a follow-up question about the current question
+		b.handleFollowUp(message)
 	}
 }
 
@@ -142,20 +301,55 @@ This bot will help you practice for your German test by presenting questions fro
 Commands:
 /start - Start the bot and get a random question
 /next - Get another random question
+/q <number> - Practise a specific question
+/learn - Go through all questions in order with answers and explanations
+/flashcards - Study with flashcards and rate how well you knew each answer
+/blitz - Answer 10 questions against the clock
 /help - Get assistance with the current question
 /stat - View your statistics
+/search <words> - Find questions by keyword
+/settings - View and change your preferences
+/mydata - Export all data stored about you
+/forgetme - Delete all data stored about you
+
+In group chats, use /quiz to practise together and /leaderboard to see who is ahead.
+
+After /help you can simply type a message to ask a follow-up question about the current question.
 
 Let's begin with your first question!`
 
 	b.sendMessage(message.Chat.ID, welcomeText)
 
 	// Send a random question
-	b.sendRandomQuestion(message.Chat.ID)
+	b.sendRandomQuestion(message.Chat.ID, message.From.ID)
 }
 
 // handleNextCommand handles the /next command
 func (b *Bot) handleNextCommand(message *tgbotapi.Message) {
-	b.sendRandomQuestion(message.Chat.ID)
+	b.sendRandomQuestion(message.Chat.ID, message.From.ID)
+}
+
+// handleQuestionCommand handles the /q command, presenting the question with the given number
+func (b *Bot) handleQuestionCommand(message *tgbotapi.Message) {
+	arg := strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "#")
+	if arg == "" {
+		b.sendMessage(message.Chat.ID, "Usage: /q <number>\n\nFor example: /q 42")
+		return
+	}
+
+	questionNum, err := strconv.Atoi(arg)
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The question number must be a number, for example /q 42.")
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Question #%d does not exist. Use /search to find questions by keyword.", questionNum))
+		return
+	}
+
+	b.presentQuestion(message.Chat.ID, message.From.ID, question)
 }
 
 // handleHelpCommand handles the /help command
@@ -167,46 +361,55 @@ func (b *Bot) handleHelpCommand(message *tgbotapi.Message) {
 	}
 
 	// Find the current question for this user
-	var currentQuestion *models.Question
-	for i := range b.questions {
-		if b.questions[i].Number == questionNum {
-			currentQuestion = &b.questions[i]
-			break
-		}
-	}
+	currentQuestion := b.findQuestion(questionNum)
 
 	if currentQuestion == nil {
 		b.sendMessage(message.Chat.ID, "Sorry, I couldn't find your current question. Please use /next to get a new question.")
 		return
 	}
 
+	b.explainQuestion(message.Chat.ID, message.From.ID, currentQuestion)
+}
+
+// explainQuestion sends the AI explanation of a question, from the cache if possible
+func (b *Bot) explainQuestion(chatID, userID int64, question *models.Question) {
+	questionNum := question.Number
+
 	// Try to get cached response first
 	cachedResponse, rightAnswer, err := b.db.GetCachedDeepseekResponse(questionNum)
 	if err != nil {
 		log.Printf("Error retrieving cached response: %v", err)
 	}
 
+	b.recordCacheLookup(cachedResponse != "")
+
 	if cachedResponse != "" {
-		b.sendMessage(message.Chat.ID, "Here's some help with this question:\n\n"+cachedResponse)
+		b.sendMessage(chatID, "Here's some help with this question:\n\n"+cachedResponse)
+		return
+	}
+
+	// If no cached response, call Deepseek API if the quota allows it
+	if ok, quotaMessage := b.checkAIQuota(userID); !ok {
+		b.sendMessage(chatID, quotaMessage)
 		return
 	}
 
-	// If no cached response, call Deepseek API
-	b.sendMessage(message.Chat.ID, "Analyzing this question, please wait a moment...")
+	b.sendMessage(chatID, "Analyzing this question, please wait a moment...")
 
-	response, rightAnswer, err := b.deepseek.AnalyzeQuestion(currentQuestion)
+	response, rightAnswer, usage, err := b.deepseek.AnalyzeQuestion(question)
+	b.recordAIUsage(userID, questionNum, aiKindHelp, usage)
 	if err != nil {
 		log.Printf("Error calling Deepseek API: %v", err)
-		b.sendMessage(message.Chat.ID, "Sorry, I couldn't analyze this question. Please try again later.")
+		b.sendMessage(chatID, "Sorry, I couldn't analyze this question. Please try again later.")
 		return
 	}
 
 	// Cache the response
-	if err := b.db.CacheDeepseekResponse(questionNum, response, rightAnswer); err != nil {
+	if err := b.cacheResponse(questionNum, response, rightAnswer); err != nil {
 		log.Printf("Error caching Deepseek response: %v", err)
 	}
 
-	b.sendMessage(message.Chat.ID, "Here's some help with this question:\n\n"+response)
+	b.sendMessage(chatID, "Here's some help with this question:\n\n"+response)
 }
 
 // handleStatCommand handles the /stat command
@@ -231,6 +434,15 @@ Correct Answers: %d ✅
 Incorrect Answers: %d ❌
 Accuracy: %.1f%%`, total, correct, incorrect, accuracy)
 
+	flashcards, err := b.db.CountFlashcardReviews(message.From.ID)
+	if err != nil {
+		log.Printf("Error counting flashcard reviews: %v", err)
+	}
+	if flashcards > 0 {
+		statMessage += fmt.Sprintf("\nFlashcards Reviewed: %d 🃏", flashcards)
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
 	if total > 0 {
 		// Get most frequently incorrect questions
 		incorrectQuestions, err := b.db.GetMostFrequentIncorrectQuestions(message.From.ID, 3)
@@ -241,45 +453,67 @@ Accuracy: %.1f%%`, total, correct, incorrect, accuracy)
 		if len(incorrectQuestions) > 0 {
 			statMessage += "\n\nMost Challenging Questions:\n"
 			for i, q := range incorrectQuestions {
-				for _, question := range b.questions {
-					if question.Number == q.QuestionNumber {
-						// Truncate long questions
-						questionText := question.Question
-						if len(questionText) > 50 {
-							questionText = questionText[:47] + "..."
-						}
-						statMessage += fmt.Sprintf("%d. Question #%d: %s\n", i+1, question.Number, questionText)
-						break
-					}
+				question := b.findQuestion(q.QuestionNumber)
+				if question == nil {
+					continue
 				}
+				// Truncate long questions
+				statMessage += fmt.Sprintf("%d. Question #%d: %s\n", i+1, question.Number, truncateRunes(question.Question, 50))
+
+				// Let the user practise the question again with a tap
+				callbackData := practiceCallbackPrefix + strconv.Itoa(question.Number)
+				button := tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Practise #%d again", question.Number), callbackData)
+				keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(button))
 			}
 		}
 	}
 
-	b.sendMessage(message.Chat.ID, statMessage)
+	if len(keyboard) == 0 {
+		b.sendMessage(message.Chat.ID, statMessage)
+	} else {
+		msg := tgbotapi.NewMessage(message.Chat.ID, statMessage)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+		if _, err := b.api.Send(msg); err != nil {
+			log.Printf("Error sending statistics: %v", err)
+		}
+	}
+
+	if total+flashcards > 0 {
+		b.sendStatChart(message.Chat.ID, message.From.ID)
+	}
 }
 
-// handleCallback processes callback queries from inline buttons
+// handleCallback processes callback queries from inline buttons, dispatching them by the
+// prefix of their data
 func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
-	startTime := time.Now()
 	log.Printf("Handling callback from user %s (ID: %d) with data: %s",
 		callback.From.UserName, callback.From.ID, callback.Data)
 
-	if !strings.HasPrefix(callback.Data, callbackPrefix) {
+	route, _, _ := strings.Cut(callback.Data, ":")
+	handler, ok := b.callbacks[route]
+	if !ok {
 		log.Printf("Invalid callback prefix: %s", callback.Data)
+		b.sendCallbackResponse(callback.ID, "")
 		return
 	}
 
-	// Extract answer number from callback data
+	handler(callback)
+}
+
+// handleAnswerCallback grades the answer to a presented question
+func (b *Bot) handleAnswerCallback(callback *tgbotapi.CallbackQuery) {
+	startTime := time.Now()
+
+	// Extract presentation ID and answer number from callback data
 	parts := strings.Split(strings.TrimPrefix(callback.Data, callbackPrefix), ":")
 	if len(parts) != 2 {
 		log.Printf("Invalid callback format: %s", callback.Data)
 		return
 	}
 
-	questionNum, err := strconv.Atoi(parts[0])
+	presentationID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		log.Printf("Invalid question number in callback: %v", err)
+		log.Printf("Invalid presentation ID in callback: %v", err)
 		return
 	}
 
@@ -289,96 +523,77 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 		return
 	}
 
-	log.Printf("User selected answer %d for question %d", answerNum, questionNum)
+	// Accept only the first answer to a presentation, and only from the user it was shown to
+	presentation, err := b.db.ClaimPresentation(presentationID, callback.From.ID)
+	if errors.Is(err, database.ErrNotPresentedToUser) {
+		// The question stays open for the user it was asked to
+		b.sendCallbackResponse(callback.ID, notYourQuestionText)
+		return
+	}
+	if err != nil {
+		log.Printf("Error claiming presentation %d: %v", presentationID, err)
+		b.sendCallbackResponse(callback.ID, "Sorry, I couldn't process your answer. Please try again.")
+		return
+	}
+	if presentation == nil {
+		log.Printf("Rejected stale answer of user %d to presentation %d", callback.From.ID, presentationID)
+		b.rejectStaleAnswer(callback, staleAnswerText)
+		return
+	}
+
+	log.Printf("User selected answer %d for question %d", answerNum, presentation.QuestionNumber)
 
 	// Always acknowledge the callback immediately to prevent "query is too old" errors
 	b.sendCallbackResponse(callback.ID, "Processing your answer...")
 
-	// Find the question
-	var question *models.Question
-	for i := range b.questions {
-		if b.questions[i].Number == questionNum {
-			question = &b.questions[i]
-			break
-		}
-	}
-
+	question := b.findQuestion(presentation.QuestionNumber)
 	if question == nil {
-		log.Printf("Question %d not found", questionNum)
-		b.sendMessage(callback.Message.Chat.ID, "Sorry, this question is no longer available.")
+		log.Printf("Question %d not found", presentation.QuestionNumber)
+		b.sendMessage(presentation.ChatID, "Sorry, this question is no longer available.")
 		return
 	}
 
-	// First, check if we have a cached response to determine the right answer
-	cachedResponse := ""
-	rightAnswer := question.RightAnswer
-	isCorrect := false
-
-	// Try to get cached response first to avoid API calls
-	log.Printf("Checking for cached response for question %d", questionNum)
-	cachedResp, cachedRightAnswer, err := b.db.GetCachedDeepseekResponse(questionNum)
-	if err == nil && cachedRightAnswer != -1 {
-		log.Printf("Found cached response for question %d with right answer: %d",
-			questionNum, cachedRightAnswer)
-		rightAnswer = cachedRightAnswer
-		cachedResponse = cachedResp
-	} else {
-		log.Printf("No cached response found for question %d or error: %v", questionNum, err)
+	// The result replaces the question message the answer was given in
+	var target *questionMessage
+	if callback.Message != nil {
+		target = &questionMessage{id: callback.Message.MessageID, photo: len(callback.Message.Photo) > 0}
 	}
+	b.processAnswer(presentation.ChatID, callback.From.ID, question, answerNum, startTime, target)
+}
 
-	// Determine if the answer is correct based on what we know
-	if rightAnswer != -1 {
-		isCorrect = (answerNum == rightAnswer)
-		log.Printf("Answer correctness determined: %v (user: %d, correct: %d)",
-			isCorrect, answerNum, rightAnswer)
-	}
+// processAnswer grades a user's answer, records it and shows the result in the question
+// message, or in a new message if target is nil.
+// If the right answer isn't known yet, it is determined with the AI in the background.
+func (b *Bot) processAnswer(chatID, userID int64, question *models.Question, answerNum int, startTime time.Time, target *questionMessage) {
+	questionNum := question.Number
+
+	// Check the cache and the catalogue to determine the right answer
+	rightAnswer := b.knownRightAnswer(question)
+	isCorrect := rightAnswer != -1 && answerNum == rightAnswer
 
 	// Save the user activity
-	if err := b.db.SaveUserActivity(callback.From.ID, questionNum, answerNum, isCorrect); err != nil {
+	if err := b.db.SaveUserActivity(userID, questionNum, answerNum, isCorrect); err != nil {
 		log.Printf("Error saving user activity: %v", err)
 	} else {
 		log.Printf("Saved user activity for question %d", questionNum)
 	}
 
-	// Prepare initial response message
-	var responseText string
-
 	if rightAnswer != -1 {
 		// We already know the right answer, respond immediately
-		if isCorrect {
-			responseText = "✅ Correct! Well done!\n\nUse /help to get more information about this question or /next for a new question."
-		} else {
-			correctAnswerText := "Unknown"
-			if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
-				correctAnswerText = question.Answers[rightAnswer]
-			}
-			responseText = fmt.Sprintf("❌ Sorry, that's not correct. The right answer is: %s\n\nUse /help to get more information or /next for a new question.", correctAnswerText)
+		verdict := "✅ Correct! Well done!"
+		if !isCorrect {
+			verdict = "❌ Sorry, that's not correct."
 		}
-
-		b.sendMessage(callback.Message.Chat.ID, responseText)
+		b.updateQuestionMessage(chatID, target, answerResultText(question, answerNum, rightAnswer, verdict), resultKeyboard(question))
 		log.Printf("Sent immediate response for question %d (%.2fs)",
 			questionNum, time.Since(startTime).Seconds())
 		return
 	}
 
-	// If we don't know the right answer yet and there's no cached response,
-	// inform the user we're processing their answer, but don't wait for Deepseek
-	userAnswer := "Unknown"
-	if answerNum >= 0 && answerNum < len(question.Answers) {
-		userAnswer = question.Answers[answerNum]
-	}
-
-	// Send initial message and store the message ID for later editing
-	initialMsg := fmt.Sprintf("Your answer: \"%s\"\n\nAnalyzing...", userAnswer)
-	sentMsg, err := b.api.Send(tgbotapi.NewMessage(callback.Message.Chat.ID, initialMsg))
-	if err != nil {
-		log.Printf("Error sending initial message: %v", err)
-		return
-	}
-	initialMessageID := sentMsg.MessageID
-	log.Printf("Sent initial message with ID %d", initialMessageID)
+	// We don't know the right answer yet: show the chosen answer without buttons
+	// while the AI analyzes the question, but don't wait for it
+	target = b.updateQuestionMessage(chatID, target, answerResultText(question, answerNum, -1, "Analyzing..."), nil)
 
-	// Launch a goroutine to handle the Deepseek API call without blocking
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -388,95 +603,95 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 
 		log.Printf("Starting async Deepseek analysis for question %d (may take up to 60s)", questionNum)
 
-		// Check again if we have a cached response (might have been added by another request)
-		cachedResp, cachedRightAnswer, err := b.db.GetCachedDeepseekResponse(questionNum)
-		if err == nil && cachedRightAnswer != -1 && cachedResp != "" {
-			log.Printf("Found cached response in async handler for question %d", questionNum)
-			rightAnswer = cachedRightAnswer
-			cachedResponse = cachedResp
-		} else if cachedResponse == "" {
+		// Check again if the right answer is known (might have been added by another request)
+		rightAnswer := b.knownRightAnswer(question)
+		b.recordCacheLookup(rightAnswer != -1)
+
+		if rightAnswer == -1 {
+			if ok, quotaMessage := b.checkAIQuota(userID); !ok {
+				b.updateQuestionMessage(chatID, target, answerResultText(question, answerNum, -1, quotaMessage), resultKeyboard(question))
+				return
+			}
+
 			// No cached response, call Deepseek API with longer timeout
-			resp, rightAns, err := b.deepseek.AnalyzeQuestion(question)
+			response, rightAns, usage, err := b.deepseek.AnalyzeQuestion(question)
+			b.recordAIUsage(userID, questionNum, aiKindAnswer, usage)
 			if err != nil {
 				log.Printf("Error calling Deepseek API asynchronously: %v", err)
-				b.editMessage(callback.Message.Chat.ID, initialMessageID,
-					fmt.Sprintf("Your answer: \"%s\"\n\nI couldn't determine the correct answer at this time. Please use /help for more information about this question.", userAnswer))
+				b.updateQuestionMessage(chatID, target,
+					answerResultText(question, answerNum, -1, "I couldn't determine the correct answer at this time. Tap Explain to learn more about this question."),
+					resultKeyboard(question))
 				return
 			}
 
-			log.Printf("Received Deepseek analysis for question %d with right answer: %d",
-				questionNum, rightAns)
-
-			// Format the updated message
-			updatedMessage := fmt.Sprintf("Your answer: \"%s\"\n\n%s\n\nUse /next to practice with a new question",
-				userAnswer, resp)
+			log.Printf("Received Deepseek analysis for question %d with right answer: %d", questionNum, rightAns)
 
-			// Edit the original message with the Deepseek response
-			b.editMessage(callback.Message.Chat.ID, initialMessageID, updatedMessage)
-			log.Printf("Updated message %d with Deepseek response (length: %d)", initialMessageID, len(resp))
-
-			// Cache the response
-			if err := b.db.CacheDeepseekResponse(questionNum, resp, rightAns); err != nil {
+			if err := b.cacheResponse(questionNum, response, rightAns); err != nil {
 				log.Printf("Error caching Deepseek response: %v", err)
 			} else {
 				log.Printf("Cached Deepseek response for question %d", questionNum)
 			}
-
 			rightAnswer = rightAns
-			cachedResponse = resp
 		}
 
-		// Now determine if the answer was correct based on Deepseek's analysis
+		verdict := "I couldn't determine the correct answer. Tap Explain to learn more about this question."
 		if rightAnswer != -1 {
-			isCorrect = (answerNum == rightAnswer)
-
-			// Update the activity record with the correct status
-			// This might require adding an update method to the database
-			log.Printf("Async result: User's answer for question %d was %v",
-				questionNum, isCorrect)
-
-			// Prepare correctness indicator
-			var correctnessText string
-			if isCorrect {
-				correctnessText = "✅ Based on my analysis, your answer was correct!"
-			} else {
-				correctAnswerText := "Unknown"
-				if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
-					correctAnswerText = question.Answers[rightAnswer]
-				}
-				correctnessText = fmt.Sprintf("❌ Based on my analysis, the correct answer is: %s", correctAnswerText)
-			}
-
-			// If we already edited the message with the full response, there's no need to do it again
-			// But if we got a cached response we might need to add the correctness info
-			if cachedResponse != "" && len(cachedResponse) > 0 {
-				updatedMessage := fmt.Sprintf("Your answer: \"%s\"\n\n%s\n\n%s\n\nUse /next to practice with a new question",
-					userAnswer, correctnessText, cachedResponse)
-				b.editMessage(callback.Message.Chat.ID, initialMessageID, updatedMessage)
-				log.Printf("Updated message %d with cached response and correctness info", initialMessageID)
+			log.Printf("Async result: User's answer for question %d was %v", questionNum, answerNum == rightAnswer)
+			verdict = "❌ Based on my analysis, your answer was not correct."
+			if answerNum == rightAnswer {
+				verdict = "✅ Based on my analysis, your answer was correct!"
 			}
 		}
+		b.updateQuestionMessage(chatID, target, answerResultText(question, answerNum, rightAnswer, verdict), resultKeyboard(question))
 	}()
 }
 
-// sendRandomQuestion sends a random question to the user
-func (b *Bot) sendRandomQuestion(chatID int64) {
-	if len(b.questions) == 0 {
+// sendRandomQuestion sends a random question for the user to the chat
+func (b *Bot) sendRandomQuestion(chatID, userID int64) {
+	question := b.pickQuestion(userID)
+	if question == nil {
 		b.sendMessage(chatID, "No questions available. Please try again later.")
 		return
 	}
 
-	userID := chatID // In private chats, the Chat ID equals the User ID
+	b.presentQuestion(chatID, userID, question)
+}
+
+// pickQuestion chooses the next question for the user, preferring questions they have never
+// answered, then questions answered longest ago. It returns nil if there are no questions.
+func (b *Bot) pickQuestion(userID int64) *models.Question {
+	questions := b.questions()
+	if len(questions) == 0 {
+		return nil
+	}
 
 	// Initialize recent questions map for this user if needed
 	if _, exists := b.recentlyAsked[userID]; !exists {
 		b.recentlyAsked[userID] = make(map[int]time.Time)
 	}
 
+	// Questions the user marked as known are left out, unless that leaves nothing to ask
+	candidates := questions
+	known, err := b.db.GetKnownQuestions(userID)
+	if err != nil {
+		log.Printf("Error getting known questions of user %d: %v", userID, err)
+	}
+	if len(known) > 0 {
+		var unknown []models.Question
+		for _, q := range questions {
+			if !known[q.Number] {
+				unknown = append(unknown, q)
+			}
+		}
+		if len(unknown) > 0 {
+			candidates = unknown
+		}
+	}
+
 	var question models.Question
 
 	// Step 1: Try to find a question the user has never answered before
-	unansweredQuestions, err := b.db.GetUnansweredQuestions(userID, b.questions)
+	unansweredQuestions, err := b.db.GetUnansweredQuestions(userID, candidates)
 	if err == nil && len(unansweredQuestions) > 0 {
 		// Select a random question from unanswered ones
 		rand.Seed(time.Now().UnixNano())
@@ -485,7 +700,7 @@ func (b *Bot) sendRandomQuestion(chatID int64) {
 		log.Printf("Found unanswered question #%d for user %d", question.Number, userID)
 	} else {
 		// Step 2: If all questions have been answered, find questions answered long ago
-		oldQuestions, err := b.db.GetLeastRecentlyAnsweredQuestions(userID, b.questions)
+		oldQuestions, err := b.db.GetLeastRecentlyAnsweredQuestions(userID, candidates)
 		if err == nil && len(oldQuestions) > 0 {
 			// Select a question answered long ago, avoiding recently asked ones if possible
 			found := false
@@ -510,8 +725,8 @@ func (b *Bot) sendRandomQuestion(chatID int64) {
 		} else {
 			// Step 3: Fallback to completely random selection
 			rand.Seed(time.Now().UnixNano())
-			randomIndex := rand.Intn(len(b.questions))
-			question = b.questions[randomIndex]
+			randomIndex := rand.Intn(len(candidates))
+			question = candidates[randomIndex]
 			log.Printf("Falling back to random question #%d for user %d", question.Number, userID)
 		}
 	}
@@ -526,119 +741,74 @@ func (b *Bot) sendRandomQuestion(chatID int64) {
 		}
 	}
 
-	// Store the user's current question
-	b.userQuestions[userID] = question.Number
+	return &question
+}
 
-	// Prepare message text
-	var messageText string
-	if question.Image != "" {
-		// If the question has an image, just send the number
-		messageText = fmt.Sprintf("Question #%d:", question.Number)
-	} else {
-		// Otherwise, include the question text
-		messageText = fmt.Sprintf("Question #%d: %s", question.Number, question.Question)
-	}
+// presentQuestion makes the question the user's current one and sends it with its answer buttons
+func (b *Bot) presentQuestion(chatID, userID int64, question *models.Question) {
+	// Store the user's current question and start a fresh follow-up conversation
+	b.userQuestions[userID] = question.Number
+	delete(b.conversations, userID)
 
-	// Check if the question has an image
-	if question.Image != "" {
-		// Send the image first
-		imagePath := filepath.Join("assets", question.Image)
-		b.sendImage(chatID, imagePath, messageText)
-	} else {
-		// Send text message only
-		b.sendMessage(chatID, messageText)
+	// Present the question as a native Telegram poll if the user prefers that
+	if b.usesPolls(userID) && b.sendQuestionPoll(chatID, userID, question) {
+		return
 	}
 
-	// Prepare answer buttons
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i, answer := range question.Answers {
-		callbackData := fmt.Sprintf("%s%d:%d", callbackPrefix, question.Number, i)
-		button := tgbotapi.NewInlineKeyboardButtonData(answer, callbackData)
-		row := []tgbotapi.InlineKeyboardButton{button}
-		keyboard = append(keyboard, row)
+	// Every presentation accepts a single answer
+	presentationID, err := b.db.CreatePresentation(userID, chatID, question.Number)
+	if err != nil {
+		log.Printf("Error creating presentation of question %d: %v", question.Number, err)
+		b.sendMessage(chatID, "Sorry, I couldn't show the question. Please try /next again.")
+		return
 	}
+	keyboard := answerKeyboard(question, presentationID)
 
-	// If no answers provided, show a default option
-	if len(keyboard) == 0 {
-		callbackData := fmt.Sprintf("%s%d:%d", callbackPrefix, question.Number, 0)
-		button := tgbotapi.NewInlineKeyboardButtonData("Not sure (no options provided)", callbackData)
-		row := []tgbotapi.InlineKeyboardButton{button}
-		keyboard = append(keyboard, row)
+	// The question and its answer buttons are sent as a single message, which is later
+	// edited to show the result
+	if question.Image != "" {
+		imagePath := b.catalogue.ImagePath(question.Image)
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(imagePath))
+		photo.Caption = fmt.Sprintf("<b>Question #%d:</b>", question.Number)
+		photo.ParseMode = tgbotapi.ModeHTML
+		photo.ReplyMarkup = keyboard
+		_, err := b.api.Send(photo)
+		if err == nil {
+			return
+		}
+		log.Printf("Error sending image %s, sending the question as text: %v", imagePath, err)
 	}
 
-	// Send answers as inline keyboard
-	answerText := "Please select your answer:"
-	msg := tgbotapi.NewMessage(chatID, answerText)
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	msg := tgbotapi.NewMessage(chatID, questionText(question))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
 	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending answers message: %v", err)
+		log.Printf("Error sending question message: %v", err)
 	}
 }
 
-// sendMessage sends a text message
+// sendMessage sends a text message, rendering Markdown as Telegram HTML and
+// splitting it into several messages when it exceeds Telegram's size limit
 func (b *Bot) sendMessage(chatID int64, text string) {
-	msg := tgbotapi.NewMessage(chatID, text)
-
-	// Check if the text appears to be markdown by looking for markdown syntax
-	if strings.Contains(text, "```") ||
-		strings.Contains(text, "**") ||
-		strings.Contains(text, "*") ||
-		strings.Contains(text, "##") ||
-		strings.Contains(text, "#") ||
-		strings.Contains(text, "`") {
-		log.Printf("Detected markdown content, setting parse mode to MarkdownV2")
-		// Escape special characters required by Telegram's MarkdownV2
-		//escapedText := escapeMarkdown(text)
-
-		msg.Text = text
-		msg.ParseMode = tgbotapi.ModeMarkdownV2
-	} else {
-		msg.ParseMode = tgbotapi.ModeHTML
-	}
-
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending message: %v", err)
-
-		// If sending fails with markdown, try without formatting
-		if msg.ParseMode == tgbotapi.ModeMarkdownV2 {
-			log.Printf("Markdown rendering failed, falling back to plain text")
-			plainMsg := tgbotapi.NewMessage(chatID, text)
-			if _, err := b.api.Send(plainMsg); err != nil {
-				log.Printf("Plain text fallback also failed: %v", err)
-			}
-		}
+	for _, chunk := range renderMessage(text) {
+		b.sendHTML(chatID, chunk)
 	}
 }
 
-// sendMarkdownMessage sends a text message with MarkdownV2 formatting
-func (b *Bot) sendMarkdownMessage(chatID int64, text string) {
-	escapedText := escapeMarkdown(text)
-	msg := tgbotapi.NewMessage(chatID, escapedText)
-	msg.ParseMode = tgbotapi.ModeMarkdownV2
+// sendHTML sends a single rendered chunk, falling back to plain text if Telegram rejects the markup
+func (b *Bot) sendHTML(chatID int64, chunk string) {
+	msg := tgbotapi.NewMessage(chatID, chunk)
+	msg.ParseMode = tgbotapi.ModeHTML
 
 	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending markdown message: %v", err)
-	}
-}
+		log.Printf("Error sending message: %v", err)
 
-// escapeMarkdown escapes special characters for Telegram's MarkdownV2 format
-func escapeMarkdown(text string) string {
-	// Characters that need escaping in MarkdownV2: _*[]()~`>#+-=|{}.!
-	specialChars := []string{"_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!"}
-
-	// Don't escape characters within code blocks
-	parts := strings.Split(text, "```")
-	for i := 0; i < len(parts); i++ {
-		// Only escape characters in non-code parts (even indices)
-		if i%2 == 0 {
-			for _, char := range specialChars {
-				parts[i] = strings.ReplaceAll(parts[i], char, "\\"+char)
-			}
+		log.Printf("HTML rendering failed, falling back to plain text")
+		plainMsg := tgbotapi.NewMessage(chatID, htmlToPlain(chunk))
+		if _, err := b.api.Send(plainMsg); err != nil {
+			log.Printf("Plain text fallback also failed: %v", err)
 		}
 	}
-
-	// Rejoin the parts
-	return strings.Join(parts, "```")
 }
 
 // sendImage sends an image with caption
@@ -662,35 +832,29 @@ func (b *Bot) sendCallbackResponse(callbackID, text string) {
 	}
 }
 
-// editMessage edits an existing message
+// editMessage edits an existing message. If the rendered text no longer fits
+// into one message, the remainder is sent as follow-up messages.
 func (b *Bot) editMessage(chatID int64, messageID int, newText string) {
-	edit := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-
-	// Check if the text appears to be markdown
-	if strings.Contains(newText, "```") ||
-		strings.Contains(newText, "**") ||
-		strings.Contains(newText, "*") ||
-		strings.Contains(newText, "##") ||
-		strings.Contains(newText, "#") ||
-		strings.Contains(newText, "`") {
-		// Escape special characters required by Telegram's MarkdownV2
-		escapedText := escapeMarkdown(newText)
-		edit.Text = escapedText
-		edit.ParseMode = tgbotapi.ModeMarkdownV2
-	} else {
-		edit.ParseMode = tgbotapi.ModeHTML
+	chunks := renderMessage(newText)
+	if len(chunks) == 0 {
+		log.Printf("Not editing message %d with empty text", messageID)
+		return
 	}
 
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, chunks[0])
+	edit.ParseMode = tgbotapi.ModeHTML
+
 	if _, err := b.api.Send(edit); err != nil {
 		log.Printf("Error editing message: %v", err)
 
-		// If editing fails with markdown, try without formatting
-		if edit.ParseMode == tgbotapi.ModeMarkdownV2 {
-			log.Printf("Markdown editing failed, falling back to plain text")
-			plainEdit := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-			if _, err := b.api.Send(plainEdit); err != nil {
-				log.Printf("Plain text edit fallback also failed: %v", err)
-			}
+		log.Printf("HTML editing failed, falling back to plain text")
+		plainEdit := tgbotapi.NewEditMessageText(chatID, messageID, htmlToPlain(chunks[0]))
+		if _, err := b.api.Send(plainEdit); err != nil {
+			log.Printf("Plain text edit fallback also failed: %v", err)
 		}
 	}
+
+	for _, chunk := range chunks[1:] {
+		b.sendHTML(chatID, chunk)
+	}
 }
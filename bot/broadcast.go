@@ -0,0 +1,267 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	broadcastRatePerSecond = 25               // Stays below Telegram's ~30 messages per second, leaving room for regular replies
+	broadcastBatchSize     = 100              // Recipients loaded from the database at a time
+	broadcastIdleInterval  = 30 * time.Second // How often the worker checks for work when it isn't woken up
+)
+
+// handleAdminBroadcast queues a broadcast or controls an existing one
+func (b *Bot) handleAdminBroadcast(message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)[2:]
+
+	if len(args) >= 1 && args[0] == "status" {
+		b.handleBroadcastStatus(message, args[1:])
+		return
+	}
+
+	// A control command is never queued as a broadcast, even with a missing or bad ID
+	if len(args) >= 1 && (args[0] == "pause" || args[0] == "resume" || args[0] == "cancel") {
+		var id int64
+		var err error
+		if len(args) == 2 {
+			id, err = strconv.ParseInt(args[1], 10, 64)
+		}
+		if len(args) != 2 || err != nil {
+			b.sendMessage(message.Chat.ID, "Usage: /admin broadcast <pause|resume|cancel> <id>")
+			return
+		}
+		b.handleBroadcastControl(message, args[0], id)
+		return
+	}
+
+	// Keep the text exactly as typed, including line breaks
+	text := strings.TrimSpace(strings.SplitN(message.Text, "broadcast", 2)[1])
+	if text == "" {
+		b.sendMessage(message.Chat.ID, "Usage: /admin broadcast <text>")
+		return
+	}
+	if textLength(text) > telegramMessageLimit {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("The broadcast is too long, please keep it under %d characters.", telegramMessageLimit))
+		return
+	}
+
+	id, recipients, err := b.db.CreateBroadcast(text, message.From.ID)
+	if err != nil {
+		log.Printf("Error creating broadcast: %v", err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't queue the broadcast.")
+		return
+	}
+
+	log.Printf("Queued broadcast %d for %d recipients", id, recipients)
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("Broadcast %d queued for %d users. Use /admin broadcast status %d to follow its progress.", id, recipients, id))
+	b.wakeBroadcaster()
+}
+
+// handleBroadcastControl pauses, resumes or cancels a broadcast
+func (b *Bot) handleBroadcastControl(message *tgbotapi.Message, action string, id int64) {
+	var from, to string
+	switch action {
+	case "pause":
+		from, to = models.BroadcastRunning, models.BroadcastPaused
+	case "resume":
+		from, to = models.BroadcastPaused, models.BroadcastRunning
+	case "cancel":
+		from, to = models.BroadcastRunning, models.BroadcastCancelled
+	}
+
+	changed, err := b.db.UpdateBroadcastStatus(id, from, to)
+	if err == nil && !changed && action == "cancel" {
+		// Paused broadcasts can be cancelled too
+		changed, err = b.db.UpdateBroadcastStatus(id, models.BroadcastPaused, to)
+	}
+	if err != nil {
+		log.Printf("Error updating broadcast %d: %v", id, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't update the broadcast.")
+		return
+	}
+	if !changed {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Broadcast %d doesn't exist or can't be changed from its current status.", id))
+		return
+	}
+
+	log.Printf("Broadcast %d is now %s", id, to)
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("Broadcast %d is now %s.", id, to))
+	b.wakeBroadcaster()
+}
+
+// handleBroadcastStatus shows the progress of one broadcast or lists the recent ones
+func (b *Bot) handleBroadcastStatus(message *tgbotapi.Message, args []string) {
+	var broadcasts []models.Broadcast
+
+	if len(args) == 1 {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			b.sendMessage(message.Chat.ID, "The broadcast ID must be a number.")
+			return
+		}
+		bc, err := b.db.GetBroadcast(id)
+		if err != nil {
+			log.Printf("Error getting broadcast %d: %v", id, err)
+		}
+		if bc == nil {
+			b.sendMessage(message.Chat.ID, fmt.Sprintf("Broadcast %d doesn't exist.", id))
+			return
+		}
+		broadcasts = append(broadcasts, *bc)
+	} else {
+		recent, err := b.db.GetRecentBroadcasts(5)
+		if err != nil {
+			log.Printf("Error getting recent broadcasts: %v", err)
+			b.sendMessage(message.Chat.ID, "Sorry, I couldn't retrieve the broadcasts.")
+			return
+		}
+		broadcasts = recent
+	}
+
+	if len(broadcasts) == 0 {
+		b.sendMessage(message.Chat.ID, "No broadcasts yet.")
+		return
+	}
+
+	var report strings.Builder
+	report.WriteString("📣 Broadcasts:\n")
+	for _, bc := range broadcasts {
+		progress, err := b.db.GetBroadcastProgress(bc.ID)
+		if err != nil {
+			log.Printf("Error getting progress of broadcast %d: %v", bc.ID, err)
+		}
+
+		preview := bc.Text
+		if runeCount(preview) > 40 {
+			preview = string([]rune(preview)[:37]) + "..."
+		}
+
+		fmt.Fprintf(&report, "\n%d (%s, created %s): %s\n%s\n",
+			bc.ID, bc.Status, formatTimestamp(bc.CreatedAt), preview, formatBroadcastProgress(progress))
+	}
+
+	b.sendMessage(message.Chat.ID, report.String())
+}
+
+// formatBroadcastProgress formats delivery counts for admin reports
+func formatBroadcastProgress(p models.BroadcastProgress) string {
+	return fmt.Sprintf("%d sent, %d pending, %d failed, %d blocked", p.Sent, p.Pending, p.Failed, p.Blocked)
+}
+
+// wakeBroadcaster tells the broadcast worker that there may be new work
+func (b *Bot) wakeBroadcaster() {
+	select {
+	case b.broadcastWake <- struct{}{}:
+	default:
+	}
+}
+
+// runBroadcasts delivers queued broadcasts, one at a time, until the process exits.
+// All state is kept in the database, so running broadcasts continue after a restart.
+func (b *Bot) runBroadcasts() {
+	limiter := time.NewTicker(time.Second / broadcastRatePerSecond)
+	defer limiter.Stop()
+
+	for {
+		bc, err := b.db.GetRunningBroadcast()
+		if err != nil {
+			log.Printf("Error getting running broadcast: %v", err)
+		}
+		if bc == nil {
+			select {
+			case <-b.broadcastWake:
+			case <-time.After(broadcastIdleInterval):
+			}
+			continue
+		}
+
+		recipients, err := b.db.GetPendingDeliveries(bc.ID, broadcastBatchSize)
+		if err != nil {
+			log.Printf("Error getting recipients of broadcast %d: %v", bc.ID, err)
+			time.Sleep(broadcastIdleInterval)
+			continue
+		}
+
+		if len(recipients) == 0 {
+			b.finishBroadcast(bc)
+			continue
+		}
+
+		for _, recipient := range recipients {
+			<-limiter.C
+
+			// Stop early if the broadcast was paused or cancelled in the meantime
+			if current, err := b.db.GetBroadcast(bc.ID); err == nil && current != nil && current.Status != models.BroadcastRunning {
+				log.Printf("Broadcast %d is %s, stopping delivery", bc.ID, current.Status)
+				break
+			}
+
+			b.deliverBroadcast(bc, recipient)
+		}
+	}
+}
+
+// deliverBroadcast sends a broadcast to one recipient and records the outcome
+func (b *Bot) deliverBroadcast(bc *models.Broadcast, recipient models.BroadcastRecipient) {
+	userID := recipient.UserID
+	_, err := b.api.Send(tgbotapi.NewMessage(recipient.ChatID, bc.Text))
+
+	status, errorText := models.DeliverySent, ""
+	if err != nil {
+		errorText = err.Error()
+
+		var apiErr *tgbotapi.Error
+		switch {
+		case errors.As(err, &apiErr) && apiErr.RetryAfter > 0:
+			// Flood control: wait and leave the delivery pending so it is retried
+			log.Printf("Broadcast %d hit flood control, waiting %ds", bc.ID, apiErr.RetryAfter)
+			time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+			return
+		case errors.As(err, &apiErr) && apiErr.Code == 403:
+			// The user blocked the bot or deleted their account
+			status = models.DeliveryBlocked
+			if err := b.db.SetUserActive(userID, false); err != nil {
+				log.Printf("Error marking user %d as inactive: %v", userID, err)
+			}
+		default:
+			status = models.DeliveryFailed
+		}
+		log.Printf("Error delivering broadcast %d to user %d: %v", bc.ID, userID, err)
+	}
+
+	if err := b.db.SetDeliveryStatus(bc.ID, userID, status, errorText); err != nil {
+		log.Printf("Error saving delivery status of broadcast %d for user %d: %v", bc.ID, userID, err)
+	}
+}
+
+// finishBroadcast marks a broadcast as done and reports the result to the admin who created it
+func (b *Bot) finishBroadcast(bc *models.Broadcast) {
+	changed, err := b.db.UpdateBroadcastStatus(bc.ID, models.BroadcastRunning, models.BroadcastDone)
+	if err != nil {
+		log.Printf("Error finishing broadcast %d: %v", bc.ID, err)
+		return
+	}
+	if !changed {
+		return
+	}
+
+	progress, err := b.db.GetBroadcastProgress(bc.ID)
+	if err != nil {
+		log.Printf("Error getting progress of broadcast %d: %v", bc.ID, err)
+	}
+
+	log.Printf("Broadcast %d finished: %s", bc.ID, formatBroadcastProgress(progress))
+	if bc.CreatedBy == 0 {
+		// The admin who created it has since deleted their data
+		return
+	}
+	b.sendMessage(b.privateChatID(bc.CreatedBy), fmt.Sprintf("Broadcast %d finished: %s.", bc.ID, formatBroadcastProgress(progress)))
+}
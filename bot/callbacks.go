@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callbackHandler handles the inline button taps of one callback route
+type callbackHandler func(callback *tgbotapi.CallbackQuery)
+
+// callbackHandlers returns the handler of every callback route. The route is the part of
+// the callback data before the first ":", e.g. "learn" for "learn:go:12".
+func (b *Bot) callbackHandlers() map[string]callbackHandler {
+	return map[string]callbackHandler{
+		callbackRoute(callbackPrefix):          b.handleAnswerCallback,
+		callbackRoute(legacyCallbackPrefix):    b.handleLegacyAnswerCallback,
+		callbackRoute(nextCallbackData):        b.handleNextCallback,
+		callbackRoute(explainCallbackPrefix):   b.handleExplainCallback,
+		callbackRoute(translateCallbackPrefix): b.handleTranslateCallback,
+		callbackRoute(reportCallbackPrefix):    b.handleReportCallback,
+		callbackRoute(knownCallbackPrefix):     b.handleKnownCallback,
+		callbackRoute(practiceCallbackPrefix):  b.handlePracticeCallback,
+		callbackRoute(learnCallbackPrefix):     b.handleLearnCallback,
+		callbackRoute(flashcardCallbackPrefix): b.handleFlashcardCallback,
+		callbackRoute(blitzCallbackPrefix):     b.handleBlitzCallback,
+		callbackRoute(groupCallbackPrefix):     b.handleGroupCallback,
+		callbackRoute(forgetCallbackPrefix):    b.handleForgetCallback,
+	}
+}
+
+// callbackRoute returns the route of a callback data prefix
+func callbackRoute(prefix string) string {
+	return strings.TrimSuffix(prefix, ":")
+}
+
+// handleLegacyAnswerCallback rejects answers from keyboards sent before answers carried
+// a presentation ID, as they can't be answered anymore
+func (b *Bot) handleLegacyAnswerCallback(callback *tgbotapi.CallbackQuery) {
+	b.rejectStaleAnswer(callback, staleAnswerText)
+}
+
+// handleNextCallback sends the next question
+func (b *Bot) handleNextCallback(callback *tgbotapi.CallbackQuery) {
+	b.sendCallbackResponse(callback.ID, "")
+	if callback.Message != nil {
+		b.sendRandomQuestion(callback.Message.Chat.ID, callback.From.ID)
+	}
+}
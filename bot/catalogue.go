@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/catalogue"
+	"github.com/korjavin/lebentestbot/database"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	catalogueCheckInterval    = 30 * time.Second
+	loggedCatalogueIssues     = 10
+	reportedCatalogueWarnings = 5 // Warnings listed in the reply to /admin reload
+)
+
+// questions returns the current question catalogue, sorted by number. A reload replaces
+// the catalogue, so callers should take it once and index into that slice only.
+func (b *Bot) questions() []models.Question {
+	return b.catalogue.Questions()
+}
+
+// watchCatalogue reloads the question catalogue whenever its file changes
+func (b *Bot) watchCatalogue() {
+	ticker := time.NewTicker(catalogueCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !b.catalogue.Changed() {
+			continue
+		}
+		log.Printf("Question catalogue %s changed, reloading", b.catalogue.Path())
+		if _, _, err := b.reloadCatalogue(); err != nil {
+			log.Printf("Error reloading the question catalogue, keeping the previous one: %v", err)
+		}
+	}
+}
+
+// reloadCatalogue reads the question catalogue again and brings the stored data, the
+// search index and the grades of recorded answers in line with it. Users keep their
+// sessions; buttons of questions asked before a renumbering are answered correctly, as
+// the presentations are migrated, but a follow-up question may refer to the question that
+// has the number now. It returns the migration, if any, and the warnings found in the catalogue.
+func (b *Bot) reloadCatalogue() (*models.CatalogueMigration, []catalogue.Issue, error) {
+	warnings, err := b.catalogue.Reload()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questions := b.questions()
+	log.Printf("Reloaded %d questions of version %q from %s", len(questions), b.catalogue.Version(), b.catalogue.Path())
+	logCatalogueWarnings(warnings)
+
+	migration := migrateCatalogue(b.db, b.catalogue)
+
+	cachedResponses, err := b.db.GetCachedResponses()
+	if err != nil {
+		log.Printf("Error loading cached responses for the search index: %v", err)
+	}
+	b.searchIndex.Rebuild(questions, cachedResponses)
+
+	regradeActivities(b.db, questions)
+
+	return migration, warnings, nil
+}
+
+// migrateCatalogue moves the data stored per question along with renumbered questions and
+// archives the answers and explanations of removed ones, see database.MigrateCatalogue
+func migrateCatalogue(db *database.DB, questionBank *catalogue.Catalogue) *models.CatalogueMigration {
+	questions := questionBank.Questions()
+	ids := make(map[int]string, len(questions))
+	for _, q := range questions {
+		ids[q.Number] = q.ID
+	}
+
+	migration, err := db.MigrateCatalogue(questionBank.Version(), ids)
+	if err != nil {
+		log.Printf("Error migrating the stored data to the question catalogue: %v", err)
+		return nil
+	}
+	if migration != nil {
+		log.Printf("Migrated the stored data from catalogue version %q to %q: %d questions renumbered, %d removed, %d answers and explanations archived",
+			migration.FromVersion, migration.ToVersion, migration.Renumbered, migration.Removed, migration.Archived)
+	}
+	return migration
+}
+
+// logCatalogueWarnings logs the first warnings found in the catalogue and how many there are
+func logCatalogueWarnings(warnings []catalogue.Issue) {
+	if len(warnings) == 0 {
+		return
+	}
+	log.Printf("The question catalogue has %d warnings", len(warnings))
+	for i, warning := range warnings {
+		if i == loggedCatalogueIssues {
+			log.Printf("...and %d more", len(warnings)-loggedCatalogueIssues)
+			break
+		}
+		log.Printf("Catalogue warning: %s", warning)
+	}
+}
+
+// handleAdminReload reloads the question catalogue right away
+func (b *Bot) handleAdminReload(message *tgbotapi.Message) {
+	migration, warnings, err := b.reloadCatalogue()
+	if err != nil {
+		log.Printf("Error reloading the question catalogue: %v", err)
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("❌ The catalogue was not reloaded, the previous one is still in use:\n\n%v", err))
+		return
+	}
+
+	summary := fmt.Sprintf("📚 Reloaded %d questions from %s.", len(b.questions()), b.catalogue.Path())
+	if version := b.catalogue.Version(); version != "" {
+		summary += fmt.Sprintf("\nVersion: %s", version)
+	}
+	if migration != nil {
+		summary += fmt.Sprintf("\n\nMigrated from version %s: %d questions renumbered, %d removed, %d answers and explanations archived.",
+			orDefault(migration.FromVersion, "none"), migration.Renumbered, migration.Removed, migration.Archived)
+	}
+	if len(warnings) > 0 {
+		summary += fmt.Sprintf("\n\n%d warnings, e.g.:", len(warnings))
+		for i, warning := range warnings {
+			if i == reportedCatalogueWarnings {
+				break
+			}
+			summary += "\n• " + warning.String()
+		}
+	}
+	b.sendMessage(message.Chat.ID, summary)
+}
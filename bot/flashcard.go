@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	flashcardCallbackPrefix = "card:"
+	flashcardActionShow     = "show"
+	flashcardActionRate     = "rate"
+
+	flashcardRatingPrompt = "How well did you know it?"
+)
+
+// flashcardRatings are the self-assessment buttons, in the order of the models.Flashcard* ratings
+var flashcardRatings = []string{"🔁 Again", "😬 Hard", "🙂 Good", "😎 Easy"}
+
+// handleFlashcardsCommand handles the /flashcards command, starting a flashcard session
+func (b *Bot) handleFlashcardsCommand(message *tgbotapi.Message) {
+	b.sendFlashcard(message.Chat.ID, message.From.ID)
+}
+
+// sendFlashcard sends the next question as a flashcard, showing only the question
+func (b *Bot) sendFlashcard(chatID, userID int64) {
+	question := b.pickQuestion(userID)
+	if question == nil {
+		b.sendMessage(chatID, "No questions available. Please try again later.")
+		return
+	}
+
+	// The flashcard is the user's current question, so /help and follow-ups refer to it
+	b.userQuestions[userID] = question.Number
+	delete(b.conversations, userID)
+
+	data := fmt.Sprintf("%s%s:%d", flashcardCallbackPrefix, flashcardActionShow, question.Number)
+	msg := tgbotapi.NewMessage(chatID, flashcardFront(question))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("👀 Show answer", data)),
+	)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending flashcard: %v", err)
+	}
+}
+
+// handleFlashcardCallback handles the "Show answer" and rating buttons of a flashcard
+func (b *Bot) handleFlashcardCallback(callback *tgbotapi.CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(callback.Data, flashcardCallbackPrefix), ":")
+	if len(parts) < 2 {
+		log.Printf("Invalid flashcard callback format: %s", callback.Data)
+		return
+	}
+
+	questionNum, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Printf("Invalid question number in flashcard callback: %v", err)
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil || callback.Message == nil {
+		b.sendCallbackResponse(callback.ID, "Sorry, this question is no longer available.")
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+
+	switch {
+	case parts[0] == flashcardActionShow && len(parts) == 2:
+		b.showFlashcardAnswer(callback, chatID, messageID, question)
+	case parts[0] == flashcardActionRate && len(parts) == 3:
+		rating, err := strconv.Atoi(parts[2])
+		if err != nil || rating < models.FlashcardAgain || rating > models.FlashcardEasy {
+			log.Printf("Invalid flashcard rating in callback: %s", callback.Data)
+			return
+		}
+		b.rateFlashcard(callback, chatID, messageID, question, rating)
+	default:
+		log.Printf("Invalid flashcard callback action: %s", callback.Data)
+	}
+}
+
+// showFlashcardAnswer turns the flashcard over. If the right answer isn't known yet,
+// it is determined with the AI in the background first.
+func (b *Bot) showFlashcardAnswer(callback *tgbotapi.CallbackQuery, chatID int64, messageID int, question *models.Question) {
+	rightAnswer := b.knownRightAnswer(question)
+	if rightAnswer != -1 {
+		b.sendCallbackResponse(callback.ID, "")
+		b.editFlashcardBack(chatID, messageID, question, rightAnswer)
+		return
+	}
+
+	b.recordCacheLookup(false)
+
+	// Without the AI, the learner can still rate themselves against what they believe is right
+	if ok, _ := b.checkAIQuota(callback.From.ID); !ok {
+		b.sendCallbackResponse(callback.ID, "")
+		b.editFlashcardBack(chatID, messageID, question, -1)
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "Analyzing this question, please wait a moment...")
+
+	userID := callback.From.ID
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in flashcard goroutine: %v", r)
+			}
+		}()
+
+		response, rightAnswer, usage, err := b.deepseek.AnalyzeQuestion(question)
+		b.recordAIUsage(userID, question.Number, aiKindAnswer, usage)
+		if err != nil {
+			log.Printf("Error analyzing flashcard question %d: %v", question.Number, err)
+		} else if err := b.cacheResponse(question.Number, response, rightAnswer); err != nil {
+			log.Printf("Error caching Deepseek response: %v", err)
+		}
+
+		b.editFlashcardBack(chatID, messageID, question, rightAnswer)
+	}()
+}
+
+// editFlashcardBack shows the right answer on a flashcard together with the rating buttons
+func (b *Bot) editFlashcardBack(chatID int64, messageID int, question *models.Question, rightAnswer int) {
+	text := flashcardFront(question) + "\n\n"
+	if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
+		text += "✅ " + escapeHTML(question.Answers[rightAnswer])
+	} else {
+		text += "I couldn't determine the right answer to this question. Use /help to learn more about it."
+	}
+	text += "\n\n" + flashcardRatingPrompt
+
+	var row []tgbotapi.InlineKeyboardButton
+	for rating, label := range flashcardRatings {
+		data := fmt.Sprintf("%s%s:%d:%d", flashcardCallbackPrefix, flashcardActionRate, question.Number, rating)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data))
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(row))
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error editing flashcard: %v", err)
+	}
+}
+
+// rateFlashcard records the learner's self-assessment and sends the next flashcard
+func (b *Bot) rateFlashcard(callback *tgbotapi.CallbackQuery, chatID int64, messageID int, question *models.Question, rating int) {
+	if err := b.db.SaveFlashcardReview(callback.From.ID, question.Number, rating); err != nil {
+		log.Printf("Error saving flashcard review: %v", err)
+		b.sendCallbackResponse(callback.ID, "Sorry, I couldn't save your rating.")
+		return
+	}
+	b.sendCallbackResponse(callback.ID, "")
+
+	// Keep the card with the rating but without buttons, so it can't be rated twice
+	text := strings.TrimSuffix(callback.Message.Text, flashcardRatingPrompt) + "Your rating: " + flashcardRatings[rating]
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error editing flashcard: %v", err)
+	}
+
+	b.sendFlashcard(chatID, callback.From.ID)
+}
+
+// flashcardFront renders the question side of a flashcard as HTML
+func flashcardFront(question *models.Question) string {
+	return fmt.Sprintf("🃏 <b>Question #%d:</b> %s", question.Number, escapeHTML(question.Question))
+}
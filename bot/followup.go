@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/ai"
+)
+
+const (
+	maxFollowUpsPerQuestion = 5   // Follow-up questions allowed per presented question
+	maxFollowUpLength       = 500 // Maximum length of a follow-up question in characters
+	maxConversationMessages = 6   // Previous messages sent back to the model as context
+)
+
+// conversation holds the follow-up discussion a user is having about their current question
+type conversation struct {
+	questionNumber int
+	followUps      int
+	history        []ai.Message
+}
+
+// handleFollowUp treats free text as a follow-up question about the user's current question
+func (b *Bot) handleFollowUp(message *tgbotapi.Message) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
+
+	questionNum, exists := b.userQuestions[userID]
+	if !exists {
+		b.sendMessage(chatID, "Please use /start to get a question first. You can then ask me follow-up questions about it.")
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(chatID, "Sorry, I couldn't find your current question. Please use /next to get a new question.")
+		return
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if utf8.RuneCountInString(text) > maxFollowUpLength {
+		b.sendMessage(chatID, fmt.Sprintf("Your question is too long. Please keep it under %d characters.", maxFollowUpLength))
+		return
+	}
+
+	conv, exists := b.conversations[userID]
+	if !exists || conv.questionNumber != questionNum {
+		conv = &conversation{questionNumber: questionNum}
+		b.conversations[userID] = conv
+	}
+
+	if conv.followUps >= maxFollowUpsPerQuestion {
+		b.sendMessage(chatID, fmt.Sprintf("You've reached the limit of %d follow-up questions for this question. Use /next to continue practising.", maxFollowUpsPerQuestion))
+		return
+	}
+
+	// Seed the conversation with the explanation the user may already have seen
+	explanation, _, err := b.db.GetCachedDeepseekResponse(questionNum)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	}
+
+	if ok, quotaMessage := b.checkAIQuota(userID); !ok {
+		b.sendMessage(chatID, quotaMessage)
+		return
+	}
+
+	if _, err := b.api.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping)); err != nil {
+		log.Printf("Error sending typing action: %v", err)
+	}
+
+	reply, usage, err := b.deepseek.FollowUp(question, explanation, conv.history, text)
+	b.recordAIUsage(userID, questionNum, aiKindFollowUp, usage)
+	if err != nil {
+		log.Printf("Error calling Deepseek API for follow-up: %v", err)
+		b.sendMessage(chatID, "Sorry, I couldn't answer your question. Please try again later.")
+		return
+	}
+
+	conv.followUps++
+	conv.history = append(conv.history,
+		ai.Message{Role: "user", Content: text},
+		ai.Message{Role: "assistant", Content: reply},
+	)
+	if len(conv.history) > maxConversationMessages {
+		conv.history = conv.history[len(conv.history)-maxConversationMessages:]
+	}
+
+	b.sendMessage(chatID, reply)
+}
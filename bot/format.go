@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// telegramMessageLimit is the maximum length of a Telegram text message, measured by textLength
+const telegramMessageLimit = 4096
+
+var (
+	headingPattern     = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	bulletPattern      = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	rulePattern        = regexp.MustCompile(`^\s{0,3}([-*_]\s*){3,}$`)
+	codeSpanPattern    = regexp.MustCompile("`([^`\n]+)`")
+	boldStarPattern    = regexp.MustCompile(`\*\*([^\s<>](?:[^\n<>]*?[^\s<>])?)\*\*`)
+	boldUnderPattern   = regexp.MustCompile(`__([^\s<>_](?:[^\n<>]*?[^\s<>_])?)__`)
+	italicPattern      = regexp.MustCompile(`\*([^*\s<>](?:[^*\n<>]*[^*\s<>])?)\*`)
+	strikePattern      = regexp.MustCompile(`~~([^~\n<>]+?)~~`)
+	linkPattern        = regexp.MustCompile(`\[([^\]\[<>\n]+)\]\((https?://[^\s()<>"]+)\)`)
+	htmlEscapeReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlTagPattern     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// textBlock is a paragraph or a fenced code block of a Markdown message
+type textBlock struct {
+	lines []string // Source lines without the code fences
+	code  bool
+	lang  string
+}
+
+// renderMessage converts the Markdown produced by the AI (or plain text) into
+// Telegram HTML, split into chunks that each fit into a single message.
+// Chunks are split between paragraphs where possible, then between lines and
+// finally between words, so every chunk is valid HTML on its own.
+func renderMessage(text string) []string {
+	var chunks []string
+	current := ""
+
+	add := func(part, separator string) {
+		switch {
+		case current == "":
+			current = part
+		case textLength(current)+textLength(separator)+textLength(part) <= telegramMessageLimit:
+			current += separator + part
+		default:
+			chunks = append(chunks, current)
+			current = part
+		}
+	}
+
+	for _, block := range parseBlocks(text) {
+		for i, part := range block.split(telegramMessageLimit) {
+			separator := "\n"
+			if i == 0 {
+				separator = "\n\n"
+			}
+			add(part, separator)
+		}
+	}
+
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// htmlToPlain strips the formatting from rendered HTML, used when Telegram rejects the markup
+func htmlToPlain(text string) string {
+	return html.UnescapeString(htmlTagPattern.ReplaceAllString(text, ""))
+}
+
+// escapeHTML escapes the characters Telegram requires to be escaped in HTML mode
+func escapeHTML(text string) string {
+	return htmlEscapeReplacer.Replace(text)
+}
+
+// parseBlocks splits Markdown into paragraphs and fenced code blocks
+func parseBlocks(text string) []textBlock {
+	var blocks []textBlock
+	var current *textBlock
+
+	flush := func() {
+		if current != nil && len(current.lines) > 0 {
+			blocks = append(blocks, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if current != nil && current.code {
+			if strings.HasPrefix(trimmed, "```") {
+				flush()
+			} else {
+				current.lines = append(current.lines, line)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			current = &textBlock{code: true, lang: strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))}
+		case trimmed == "":
+			flush()
+		default:
+			if current == nil {
+				current = &textBlock{}
+			}
+			current.lines = append(current.lines, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// renderLine renders a single source line of the block
+func (tb textBlock) renderLine(line string) string {
+	if tb.code {
+		return escapeHTML(line)
+	}
+	return renderMarkdownLine(line)
+}
+
+// wrap joins rendered lines into the final HTML of the block
+func (tb textBlock) wrap(rendered []string) string {
+	body := strings.Join(rendered, "\n")
+	if !tb.code {
+		return body
+	}
+	if tb.lang != "" {
+		return `<pre><code class="language-` + escapeHTML(tb.lang) + `">` + body + "</code></pre>"
+	}
+	return "<pre>" + body + "</pre>"
+}
+
+// split renders the block into one or more parts that each fit within limit
+func (tb textBlock) split(limit int) []string {
+	overhead := textLength(tb.wrap(nil))
+
+	var parts []string
+	var group []string
+	groupLen := 0
+
+	for _, line := range tb.lines {
+		for _, piece := range splitLine(line, limit-overhead, tb.renderLine) {
+			pieceLen := textLength(piece)
+			if len(group) > 0 && overhead+groupLen+1+pieceLen > limit {
+				parts = append(parts, tb.wrap(group))
+				group, groupLen = nil, 0
+			}
+			if len(group) > 0 {
+				groupLen++
+			}
+			group = append(group, piece)
+			groupLen += pieceLen
+		}
+	}
+
+	if len(group) > 0 {
+		parts = append(parts, tb.wrap(group))
+	}
+	return parts
+}
+
+// splitLine renders a source line, splitting it between words when the result exceeds limit
+func splitLine(line string, limit int, render func(string) string) []string {
+	if rendered := render(line); textLength(rendered) <= limit {
+		return []string{rendered}
+	}
+
+	var pieces []string
+	current := ""
+	for _, word := range strings.SplitAfter(line, " ") {
+		if current != "" && textLength(render(current+word)) > limit {
+			pieces = append(pieces, render(current))
+			current = ""
+		}
+		// A single word longer than the limit is cut into runes
+		for current == "" && textLength(render(word)) > limit {
+			cut := cutToLimit(word, limit, render)
+			pieces = append(pieces, render(cut))
+			word = word[len(cut):]
+		}
+		current += word
+	}
+	if current != "" {
+		pieces = append(pieces, render(current))
+	}
+	return pieces
+}
+
+// cutToLimit returns the longest prefix of word whose rendering fits within limit
+func cutToLimit(word string, limit int, render func(string) string) string {
+	end := 0
+	for i, r := range word {
+		next := i + utf8.RuneLen(r)
+		if textLength(render(word[:next])) > limit {
+			break
+		}
+		end = next
+	}
+	if end == 0 {
+		// Always make progress, even if a single rune exceeds the limit
+		_, size := utf8.DecodeRuneInString(word)
+		end = size
+	}
+	return word[:end]
+}
+
+// renderMarkdownLine converts one line of Markdown outside code blocks to Telegram HTML
+func renderMarkdownLine(line string) string {
+	if rulePattern.MatchString(line) {
+		return "──────────"
+	}
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		return "<b>" + renderInline(m[1]) + "</b>"
+	}
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + "• " + renderInline(m[2])
+	}
+	return renderInline(line)
+}
+
+// renderInline converts inline Markdown (code, bold, italic, strikethrough and links) to Telegram HTML.
+// Emphasis is only applied to properly paired markers, so the result is always balanced.
+func renderInline(text string) string {
+	var result strings.Builder
+	last := 0
+	for _, m := range codeSpanPattern.FindAllStringSubmatchIndex(text, -1) {
+		result.WriteString(renderEmphasis(text[last:m[0]]))
+		result.WriteString("<code>" + escapeHTML(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	result.WriteString(renderEmphasis(text[last:]))
+	return result.String()
+}
+
+// renderEmphasis escapes text and converts emphasis and links to HTML tags
+func renderEmphasis(text string) string {
+	text = escapeHTML(text)
+	text = boldStarPattern.ReplaceAllString(text, "<b>$1</b>")
+	text = boldUnderPattern.ReplaceAllString(text, "<b>$1</b>")
+	text = italicPattern.ReplaceAllString(text, "<i>$1</i>")
+	text = strikePattern.ReplaceAllString(text, "<s>$1</s>")
+	return linkPattern.ReplaceAllString(text, `<a href="$2">$1</a>`)
+}
+
+// runeCount returns the length of text in characters
+func runeCount(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// textLength returns the length of text as Telegram counts it for its limits, in UTF-16
+// code units, so that an emoji outside the Basic Multilingual Plane counts twice
+func textLength(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenderMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain text", "Hello world", "Hello world"},
+		{"escaping", "a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"bold", "**bold**", "<b>bold</b>"},
+		{"bold with underscores", "__bold__", "<b>bold</b>"},
+		{"italic", "*italic*", "<i>italic</i>"},
+		{"italic nested in bold", "**bold *italic* text**", "<b>bold <i>italic</i> text</b>"},
+		{"strikethrough", "~~gone~~", "<s>gone</s>"},
+		{"unpaired markers", "2 * 3 ** 4", "2 * 3 ** 4"},
+		{"code span", "use `a<b && c`", "use <code>a&lt;b &amp;&amp; c</code>"},
+		{"no emphasis in code span", "`**x**`", "<code>**x**</code>"},
+		{"link", "[docs](https://example.com/a)", `<a href="https://example.com/a">docs</a>`},
+		{"link with & in the URL", "[docs](https://example.com/?a=1&b=2)", `<a href="https://example.com/?a=1&amp;b=2">docs</a>`},
+		{"heading", "## Title", "<b>Title</b>"},
+		{"bullet", "- item", "• item"},
+		{"rule", "---", "──────────"},
+		{"paragraphs", "one\n\n\ntwo", "one\n\ntwo"},
+		{"code fence", "```\nif a < b {\n```", "<pre>if a &lt; b {</pre>"},
+		{"code fence with language", "```go\nx := a && b\n```", `<pre><code class="language-go">x := a &amp;&amp; b</code></pre>`},
+		{"no markdown in code fence", "```\n**x**\n```", "<pre>**x**</pre>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderMessage(tt.text)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("renderMessage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMessageSplitsLongText(t *testing.T) {
+	var text strings.Builder
+	for i := 0; i < 300; i++ {
+		text.WriteString("**Paragraph** with *emphasis*, `a<b` and [a link](https://example.com/?a=1&b=2).\n\n")
+	}
+	text.WriteString("```go\n")
+	for i := 0; i < 400; i++ {
+		text.WriteString("if a < b && c > d {}\n")
+	}
+	text.WriteString("```\n\n")
+	text.WriteString(strings.Repeat("<&>", 3000) + "\n\n")
+	text.WriteString(strings.Repeat("word ", 2000) + "\n\n")
+	// Emoji outside the Basic Multilingual Plane count as two UTF-16 code units
+	text.WriteString(strings.Repeat("🇩🇪 ", 1500) + "\n\n")
+	text.WriteString(strings.Repeat("😀", 3000))
+
+	chunks := renderMessage(text.String())
+	if len(chunks) < 2 {
+		t.Fatalf("renderMessage returned %d chunks, want several", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := textLength(chunk); n > telegramMessageLimit {
+			t.Errorf("chunk %d has %d UTF-16 code units, want at most %d", i, n, telegramMessageLimit)
+		}
+		if err := checkHTML(chunk); err != "" {
+			t.Errorf("chunk %d: %s", i, err)
+		}
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		limit int
+		want  []string
+	}{
+		{"fits", "a b c", 10, []string{"a b c"}},
+		{"between words", "aaaa bbbb cccc", 10, []string{"aaaa bbbb ", "cccc"}},
+		{"escaping counts", "<< <<", 10, []string{"&lt;&lt; ", "&lt;&lt;"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"long escaped word", "<<<", 9, []string{"&lt;&lt;", "&lt;"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitLine(tt.line, tt.limit, escapeHTML)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitLine(%q, %d) = %q, want %q", tt.line, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutToLimit(t *testing.T) {
+	tests := []struct {
+		word  string
+		limit int
+		want  string
+	}{
+		{"abcdef", 3, "abc"},
+		{"<<<<", 9, "<<"},
+		{"äöü", 2, "äö"},
+		{"😀😀", 3, "😀"},
+		{"<", 2, "<"}, // A rune that never fits is still cut off
+	}
+
+	for _, tt := range tests {
+		if got := cutToLimit(tt.word, tt.limit, escapeHTML); got != tt.want {
+			t.Errorf("cutToLimit(%q, %d) = %q, want %q", tt.word, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"abc", 3},
+		{"äöü", 3},
+		{"€", 1},
+		{"😀", 2},
+		{"🇩🇪", 4},
+	}
+
+	for _, tt := range tests {
+		if got := textLength(tt.text); got != tt.want {
+			t.Errorf("textLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+var (
+	testTagPattern    = regexp.MustCompile(`<(/?)([a-z]+)[^<>]*>`)
+	testEntityPattern = regexp.MustCompile(`&(amp|lt|gt|quot);`)
+)
+
+// checkHTML returns what is wrong with the tags and escaping of rendered HTML, or ""
+func checkHTML(text string) string {
+	var open []string
+	for _, m := range testTagPattern.FindAllStringSubmatch(text, -1) {
+		if m[1] == "" {
+			open = append(open, m[2])
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != m[2] {
+			return "unbalanced </" + m[2] + ">"
+		}
+		open = open[:len(open)-1]
+	}
+	if len(open) > 0 {
+		return "unclosed <" + open[len(open)-1] + ">"
+	}
+
+	rest := testEntityPattern.ReplaceAllString(testTagPattern.ReplaceAllString(text, ""), "")
+	if strings.ContainsAny(rest, "<>&") {
+		return "unescaped <, > or &"
+	}
+	return ""
+}
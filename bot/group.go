@@ -0,0 +1,346 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	groupCallbackPrefix = "group:"
+	groupQuizDuration   = 30 * time.Second
+	leaderboardSize     = 10
+)
+
+// groupQuiz is a question posted to a group that every member can answer once
+type groupQuiz struct {
+	id          int64
+	chatID      int64
+	messageID   int
+	question    *models.Question
+	rightAnswer int
+	answers     map[int64]groupAnswer // Keyed by user ID
+	order       []int64               // User IDs in the order they answered
+}
+
+// groupAnswer is a member's answer to a group quiz
+type groupAnswer struct {
+	index int
+	name  string
+}
+
+// groupQuizzes tracks the running quiz of every group
+type groupQuizzes struct {
+	mu      sync.Mutex
+	nextID  int64
+	byGroup map[int64]*groupQuiz // Keyed by chat ID
+}
+
+// handleQuizCommand handles the /quiz command, posting a question to the group
+func (b *Bot) handleQuizCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+
+	if message.Chat.IsPrivate() {
+		b.sendMessage(chatID, "The /quiz command is meant for group chats. Add me to a group to practise together, or use /next to practise on your own.")
+		return
+	}
+
+	b.groupQuizzes.mu.Lock()
+	if _, running := b.groupQuizzes.byGroup[chatID]; running {
+		b.groupQuizzes.mu.Unlock()
+		b.sendMessage(chatID, "A quiz question is already running in this group. Please answer it first!")
+		return
+	}
+	b.groupQuizzes.nextID++
+	quiz := &groupQuiz{
+		id:      b.groupQuizzes.nextID,
+		chatID:  chatID,
+		answers: make(map[int64]groupAnswer),
+	}
+	b.groupQuizzes.byGroup[chatID] = quiz
+	b.groupQuizzes.mu.Unlock()
+
+	quiz.question, quiz.rightAnswer = b.pickGroupQuestion()
+	if quiz.question == nil {
+		b.endGroupQuiz(chatID)
+		b.sendMessage(chatID, "No questions available. Please try again later.")
+		return
+	}
+
+	// If nobody knows the right answer yet, ask the AI while the group is answering
+	if quiz.rightAnswer == -1 {
+		b.analyzeGroupQuestion(quiz, message.From.ID)
+	}
+
+	text := fmt.Sprintf("👥 Group quiz! Everyone can answer once, you have %d seconds.\n\n<b>Question #%d:</b> %s",
+		int(groupQuizDuration.Seconds()), quiz.question.Number, escapeHTML(quiz.question.Question))
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, answer := range quiz.question.Answers {
+		callbackData := fmt.Sprintf("%s%d:%d", groupCallbackPrefix, quiz.id, i)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(answer, callbackData)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		log.Printf("Error sending group quiz to chat %d: %v", chatID, err)
+		b.endGroupQuiz(chatID)
+		return
+	}
+
+	b.groupQuizzes.mu.Lock()
+	quiz.messageID = sent.MessageID
+	b.groupQuizzes.mu.Unlock()
+
+	log.Printf("Started group quiz %d in chat %d with question #%d", quiz.id, chatID, quiz.question.Number)
+	b.timers.Schedule(groupTimerKey(chatID), groupQuizDuration, func() {
+		b.revealGroupQuiz(quiz)
+	})
+}
+
+// pickGroupQuestion picks a random question, preferring questions whose right answer is known
+func (b *Bot) pickGroupQuestion() (*models.Question, int) {
+	candidates := b.questionsWithKnownAnswers()
+	if len(candidates) == 0 {
+		questions := b.questions()
+		for i := range questions {
+			if len(questions[i].Answers) > 0 {
+				candidates = append(candidates, &questions[i])
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, -1
+	}
+
+	question := candidates[rand.Intn(len(candidates))]
+	return question, b.knownRightAnswer(question)
+}
+
+// questionsWithKnownAnswers returns the questions with answer options whose right answer is known
+func (b *Bot) questionsWithKnownAnswers() []*models.Question {
+	knownAnswers, err := b.db.GetKnownRightAnswers()
+	if err != nil {
+		log.Printf("Error getting known right answers: %v", err)
+	}
+
+	questions := b.questions()
+	var known []*models.Question
+	for i := range questions {
+		q := &questions[i]
+		if len(q.Answers) == 0 {
+			continue
+		}
+		if _, ok := knownAnswers[q.Number]; ok || q.RightAnswer >= 0 {
+			known = append(known, q)
+		}
+	}
+	return known
+}
+
+// analyzeGroupQuestion asks the AI for the right answer of a group quiz question in the background.
+// The call is charged to the member who started the quiz.
+func (b *Bot) analyzeGroupQuestion(quiz *groupQuiz, userID int64) {
+	if ok, _ := b.checkAIQuota(userID); !ok {
+		return
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in group quiz analysis goroutine: %v", r)
+			}
+		}()
+
+		b.recordCacheLookup(false)
+		response, rightAnswer, usage, err := b.deepseek.AnalyzeQuestion(quiz.question)
+		b.recordAIUsage(userID, quiz.question.Number, aiKindAnswer, usage)
+		if err != nil {
+			log.Printf("Error analyzing group quiz question %d: %v", quiz.question.Number, err)
+			return
+		}
+
+		if err := b.cacheResponse(quiz.question.Number, response, rightAnswer); err != nil {
+			log.Printf("Error caching Deepseek response: %v", err)
+		}
+
+		b.groupQuizzes.mu.Lock()
+		quiz.rightAnswer = rightAnswer
+		b.groupQuizzes.mu.Unlock()
+	}()
+}
+
+// handleGroupCallback records a member's answer to a group quiz
+func (b *Bot) handleGroupCallback(callback *tgbotapi.CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(callback.Data, groupCallbackPrefix), ":")
+	if len(parts) != 2 {
+		log.Printf("Invalid group callback format: %s", callback.Data)
+		return
+	}
+
+	quizID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		log.Printf("Invalid quiz ID in callback: %v", err)
+		return
+	}
+
+	answerNum, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Printf("Invalid answer number in callback: %v", err)
+		return
+	}
+
+	if callback.Message == nil {
+		b.sendCallbackResponse(callback.ID, "This quiz question is already closed.")
+		return
+	}
+
+	b.groupQuizzes.mu.Lock()
+	defer b.groupQuizzes.mu.Unlock()
+
+	quiz, running := b.groupQuizzes.byGroup[callback.Message.Chat.ID]
+	if !running || quiz.id != quizID {
+		b.sendCallbackResponse(callback.ID, "This quiz question is already closed.")
+		return
+	}
+
+	if _, answered := quiz.answers[callback.From.ID]; answered {
+		b.sendCallbackResponse(callback.ID, "You have already answered this question.")
+		return
+	}
+
+	quiz.answers[callback.From.ID] = groupAnswer{index: answerNum, name: displayName(callback.From)}
+	quiz.order = append(quiz.order, callback.From.ID)
+	b.sendCallbackResponse(callback.ID, "Your answer has been recorded!")
+}
+
+// revealGroupQuiz closes a group quiz, reveals the right answer and updates the leaderboard
+func (b *Bot) revealGroupQuiz(quiz *groupQuiz) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic while revealing group quiz: %v", r)
+		}
+	}()
+
+	b.groupQuizzes.mu.Lock()
+	if b.groupQuizzes.byGroup[quiz.chatID] == quiz {
+		delete(b.groupQuizzes.byGroup, quiz.chatID)
+	}
+	rightAnswer := quiz.rightAnswer
+	answers := quiz.answers
+	order := quiz.order
+	b.groupQuizzes.mu.Unlock()
+
+	question := quiz.question
+	var right, wrong []string
+	for _, userID := range order {
+		answer := answers[userID]
+		correct := rightAnswer != -1 && answer.index == rightAnswer
+		if correct {
+			right = append(right, answer.name)
+		} else {
+			wrong = append(wrong, answer.name)
+		}
+
+		if err := b.db.SaveUserActivity(userID, question.Number, answer.index, correct); err != nil {
+			log.Printf("Error saving user activity: %v", err)
+		}
+		if rightAnswer != -1 {
+			if err := b.db.AddGroupScore(quiz.chatID, userID, correct); err != nil {
+				log.Printf("Error updating group score: %v", err)
+			}
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "👥 Question #%d: %s\n\n", question.Number, question.Question)
+	if rightAnswer >= 0 && rightAnswer < len(question.Answers) {
+		fmt.Fprintf(&result, "✅ The right answer is: %s\n\n", question.Answers[rightAnswer])
+		if len(right) > 0 {
+			fmt.Fprintf(&result, "🏆 Right: %s\n", strings.Join(right, ", "))
+		}
+		if len(wrong) > 0 {
+			fmt.Fprintf(&result, "❌ Wrong: %s\n", strings.Join(wrong, ", "))
+		}
+		if len(order) == 0 {
+			result.WriteString("Nobody answered this time.\n")
+		}
+	} else {
+		result.WriteString("I couldn't determine the right answer to this question, so it doesn't count for the leaderboard.\n")
+	}
+	result.WriteString("\nUse /quiz for another question or /leaderboard to see the standings.")
+
+	if quiz.messageID != 0 {
+		b.editMessage(quiz.chatID, quiz.messageID, result.String())
+	} else {
+		b.sendMessage(quiz.chatID, result.String())
+	}
+	log.Printf("Revealed group quiz %d in chat %d: %d answers", quiz.id, quiz.chatID, len(order))
+}
+
+// groupTimerKey returns the scheduler key of a group's quiz countdown
+func groupTimerKey(chatID int64) string {
+	return "group:" + strconv.FormatInt(chatID, 10)
+}
+
+// endGroupQuiz removes the running quiz of a group without revealing it
+func (b *Bot) endGroupQuiz(chatID int64) {
+	b.groupQuizzes.mu.Lock()
+	delete(b.groupQuizzes.byGroup, chatID)
+	b.groupQuizzes.mu.Unlock()
+}
+
+// handleLeaderboardCommand handles the /leaderboard command in groups
+func (b *Bot) handleLeaderboardCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+
+	if message.Chat.IsPrivate() {
+		b.sendMessage(chatID, "The leaderboard is available in group chats. Use /stat to see your own statistics.")
+		return
+	}
+
+	scores, err := b.db.GetGroupLeaderboard(chatID, leaderboardSize)
+	if err != nil {
+		log.Printf("Error getting leaderboard of chat %d: %v", chatID, err)
+		b.sendMessage(chatID, "Sorry, I couldn't retrieve the leaderboard. Please try again later.")
+		return
+	}
+
+	if len(scores) == 0 {
+		b.sendMessage(chatID, "No scores yet. Start a quiz with /quiz!")
+		return
+	}
+
+	var board strings.Builder
+	board.WriteString("🏆 Group Leaderboard:\n\n")
+	for i, score := range scores {
+		name := score.FirstName
+		if score.Username != "" {
+			name = "@" + score.Username
+		}
+		if name == "" {
+			name = fmt.Sprintf("User %d", score.UserID)
+		}
+		fmt.Fprintf(&board, "%d. %s: %d of %d right\n", i+1, name, score.Correct, score.Answered)
+	}
+
+	b.sendMessage(chatID, board.String())
+}
+
+// displayName returns a short name for a Telegram user
+func displayName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	inlineResultLimit       = 20
+	inlineCacheSeconds      = 300
+	inlineExplanationLength = 400 // Characters of the cached explanation shown on a question card
+)
+
+// handleInlineQuery answers "@bot <words or number>" queries with shareable question cards
+func (b *Bot) handleInlineQuery(query *tgbotapi.InlineQuery) {
+	log.Printf("Inline query from %s (ID: %d): %s", query.From.UserName, query.From.ID, query.Query)
+
+	var questions []models.Question
+	if strings.TrimSpace(query.Query) == "" {
+		// Without a query, suggest the first questions of the catalogue
+		questions = b.questions()
+		if len(questions) > inlineResultLimit {
+			questions = questions[:inlineResultLimit]
+		}
+	} else {
+		questions = b.searchIndex.Search(query.Query, inlineResultLimit)
+	}
+
+	results := make([]interface{}, 0, len(questions))
+	for i := range questions {
+		question := &questions[i]
+		article := tgbotapi.NewInlineQueryResultArticleHTML(
+			"q"+strconv.Itoa(question.Number),
+			fmt.Sprintf("Question #%d", question.Number),
+			b.questionCard(question),
+		)
+		article.Description = truncateRunes(question.Question, 120)
+		results = append(results, article)
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     inlineCacheSeconds,
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		log.Printf("Error answering inline query: %v", err)
+	}
+}
+
+// questionCard renders a question with its answers, the right answer and a short explanation as HTML
+func (b *Bot) questionCard(question *models.Question) string {
+	var card strings.Builder
+	fmt.Fprintf(&card, "<b>Question #%d:</b> %s\n", question.Number, escapeHTML(question.Question))
+
+	rightAnswer := b.knownRightAnswer(question)
+	for i, answer := range question.Answers {
+		marker := "▫️"
+		if i == rightAnswer {
+			marker = "✅"
+		}
+		fmt.Fprintf(&card, "\n%s %s", marker, escapeHTML(answer))
+	}
+
+	if rightAnswer < 0 || rightAnswer >= len(question.Answers) {
+		card.WriteString("\n\nThe right answer hasn't been determined yet.")
+	}
+
+	explanation, _, err := b.db.GetCachedDeepseekResponse(question.Number)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	}
+	if explanation != "" {
+		if chunks := renderMessage(truncateRunes(explanation, inlineExplanationLength)); len(chunks) > 0 {
+			card.WriteString("\n\n💡 " + chunks[0])
+		}
+	}
+
+	fmt.Fprintf(&card, "\n\nPractise for the test with @%s", b.api.Self.UserName)
+	return card.String()
+}
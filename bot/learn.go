@@ -0,0 +1,262 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	learnCallbackPrefix = "learn:"
+	learnActionGo       = "go"
+	learnActionExplain  = "explain"
+
+	learnJump = 10 // Questions skipped by the fast forward and back buttons
+)
+
+// handleLearnCommand handles the /learn command, which walks through the catalogue in order
+// with the right answer and explanation shown right away. "/learn <number>" jumps to a question.
+func (b *Bot) handleLearnCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+
+	questions := b.questions()
+	if len(questions) == 0 {
+		b.sendMessage(chatID, "No questions available. Please try again later.")
+		return
+	}
+
+	var position int
+	if arg := strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "#"); arg != "" {
+		number, err := strconv.Atoi(arg)
+		if err != nil {
+			b.sendMessage(chatID, "Usage: /learn [number]\n\nWithout a number you continue where you left off.")
+			return
+		}
+		position = number
+	} else {
+		stored, err := b.db.GetLearnPosition(userID)
+		if err != nil {
+			log.Printf("Error getting learn position of user %d: %v", userID, err)
+		}
+		position = stored
+	}
+
+	index := learnIndex(questions, position)
+	b.startLearning(userID, &questions[index])
+
+	msg := tgbotapi.NewMessage(chatID, b.learnCard(questions, index))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = b.learnKeyboard(questions, index)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending learn card: %v", err)
+	}
+}
+
+// handleLearnCallback handles the navigation and explain buttons of a learn card
+func (b *Bot) handleLearnCallback(callback *tgbotapi.CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(callback.Data, learnCallbackPrefix), ":")
+	if len(parts) != 2 {
+		log.Printf("Invalid learn callback format: %s", callback.Data)
+		return
+	}
+
+	questionNum, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Printf("Invalid question number in learn callback: %v", err)
+		return
+	}
+
+	if callback.Message == nil {
+		b.sendCallbackResponse(callback.ID, "")
+		return
+	}
+
+	questions := b.questions()
+	if len(questions) == 0 {
+		b.sendCallbackResponse(callback.ID, "Sorry, this question is no longer available.")
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+	question := &questions[learnIndex(questions, questionNum)]
+
+	switch parts[0] {
+	case learnActionGo:
+		b.sendCallbackResponse(callback.ID, "")
+		b.startLearning(callback.From.ID, question)
+		b.editLearnCard(chatID, messageID, question.Number)
+	case learnActionExplain:
+		b.explainLearnCard(callback, chatID, messageID, question)
+	default:
+		log.Printf("Invalid learn callback action: %s", callback.Data)
+	}
+}
+
+// startLearning remembers the user's position and makes the question their current one,
+// so /help and follow-up questions refer to it
+func (b *Bot) startLearning(userID int64, question *models.Question) {
+	if err := b.db.SetLearnPosition(userID, question.Number); err != nil {
+		log.Printf("Error saving learn position of user %d: %v", userID, err)
+	}
+	b.userQuestions[userID] = question.Number
+	delete(b.conversations, userID)
+}
+
+// explainLearnCard asks the AI to explain the question of a learn card and updates the card
+func (b *Bot) explainLearnCard(callback *tgbotapi.CallbackQuery, chatID int64, messageID int, question *models.Question) {
+	userID := callback.From.ID
+
+	cachedResponse, _, err := b.db.GetCachedDeepseekResponse(question.Number)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	}
+	b.recordCacheLookup(cachedResponse != "")
+
+	if cachedResponse != "" {
+		b.sendCallbackResponse(callback.ID, "")
+		b.editLearnCard(chatID, messageID, question.Number)
+		return
+	}
+
+	if ok, quotaMessage := b.checkAIQuota(userID); !ok {
+		b.sendCallbackResponse(callback.ID, "")
+		b.sendMessage(chatID, quotaMessage)
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "Analyzing this question, please wait a moment...")
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in learn explanation goroutine: %v", r)
+			}
+		}()
+
+		response, rightAnswer, usage, err := b.deepseek.AnalyzeQuestion(question)
+		b.recordAIUsage(userID, question.Number, aiKindHelp, usage)
+		if err != nil {
+			log.Printf("Error explaining question %d: %v", question.Number, err)
+			b.sendMessage(chatID, "Sorry, I couldn't analyze this question. Please try again later.")
+			return
+		}
+
+		if err := b.cacheResponse(question.Number, response, rightAnswer); err != nil {
+			log.Printf("Error caching Deepseek response: %v", err)
+		}
+
+		b.editLearnCard(chatID, messageID, question.Number)
+	}()
+}
+
+// learnIndex returns the index of the question with the given number, or of the
+// closest question in catalogue order if there is no such question
+func learnIndex(questions []models.Question, questionNum int) int {
+	for i := range questions {
+		if questions[i].Number >= questionNum {
+			return i
+		}
+	}
+	return len(questions) - 1
+}
+
+// learnCard renders the question at index with its right answer and explanation as HTML
+func (b *Bot) learnCard(questions []models.Question, index int) string {
+	question := &questions[index]
+
+	var card strings.Builder
+	fmt.Fprintf(&card, "📖 Question %d of %d\n\n<b>Question #%d:</b> %s\n",
+		index+1, len(questions), question.Number, escapeHTML(question.Question))
+
+	rightAnswer := b.knownRightAnswer(question)
+	for i, answer := range question.Answers {
+		marker := "▫️"
+		if i == rightAnswer {
+			marker = "✅"
+		}
+		fmt.Fprintf(&card, "\n%s %s", marker, escapeHTML(answer))
+	}
+
+	if rightAnswer < 0 || rightAnswer >= len(question.Answers) {
+		card.WriteString("\n\nThe right answer hasn't been determined yet. Tap Explain to find out.")
+	}
+
+	explanation, _, err := b.db.GetCachedDeepseekResponse(question.Number)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	}
+	if explanation == "" {
+		return card.String()
+	}
+
+	// Shorten the explanation until the whole card fits into one message
+	prefix := card.String() + "\n\n💡 "
+	for length := runeCount(explanation); length > 0; length = length * 9 / 10 {
+		chunks := renderMessage(truncateRunes(explanation, length))
+		if len(chunks) == 1 && textLength(prefix)+textLength(chunks[0]) <= telegramMessageLimit {
+			return prefix + chunks[0]
+		}
+	}
+	return card.String()
+}
+
+// learnKeyboard returns the navigation buttons of the learn card at index
+func (b *Bot) learnKeyboard(questions []models.Question, index int) tgbotapi.InlineKeyboardMarkup {
+	last := len(questions) - 1
+	button := func(label string, target int) tgbotapi.InlineKeyboardButton {
+		target = max(0, min(last, target))
+		data := fmt.Sprintf("%s%s:%d", learnCallbackPrefix, learnActionGo, questions[target].Number)
+		return tgbotapi.NewInlineKeyboardButtonData(label, data)
+	}
+
+	var navigation []tgbotapi.InlineKeyboardButton
+	if index > 0 {
+		navigation = append(navigation,
+			button(fmt.Sprintf("⏪ -%d", learnJump), index-learnJump),
+			button("◀️ Back", index-1))
+	}
+	if index < last {
+		navigation = append(navigation,
+			button("Next ▶️", index+1),
+			button(fmt.Sprintf("+%d ⏩", learnJump), index+learnJump))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+
+	question := &questions[index]
+	actions := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🎯 Practise", practiceCallbackPrefix+strconv.Itoa(question.Number)),
+	}
+	if explanation, _, err := b.db.GetCachedDeepseekResponse(question.Number); err == nil && explanation == "" {
+		data := fmt.Sprintf("%s%s:%d", learnCallbackPrefix, learnActionExplain, question.Number)
+		actions = append(actions, tgbotapi.NewInlineKeyboardButtonData("💡 Explain", data))
+	}
+	rows = append(rows, actions)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// editLearnCard replaces a learn card with the question with the given number, or the
+// closest one if the catalogue changed in the meantime
+func (b *Bot) editLearnCard(chatID int64, messageID int, questionNum int) {
+	questions := b.questions()
+	if len(questions) == 0 {
+		return
+	}
+	index := learnIndex(questions, questionNum)
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, b.learnCard(questions, index), b.learnKeyboard(questions, index))
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error editing learn card: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	openReportsListed         = 20
+	reportCommentPreviewRunes = 80
+	explanationPreviewRunes   = 500
+)
+
+// handleAdminReports lists the reports that haven't been resolved yet, oldest first
+func (b *Bot) handleAdminReports(message *tgbotapi.Message) {
+	reports, err := b.db.GetOpenReports(openReportsListed)
+	if err != nil {
+		log.Printf("Error getting open reports: %v", err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't retrieve the reports.")
+		return
+	}
+
+	if len(reports) == 0 {
+		b.sendMessage(message.Chat.ID, "🎉 There are no open reports.")
+		return
+	}
+
+	total, err := b.db.CountOpenReports()
+	if err != nil {
+		log.Printf("Error counting open reports: %v", err)
+		total = len(reports)
+	}
+
+	var list strings.Builder
+	fmt.Fprintf(&list, "🚩 Open reports: %d\n\n", total)
+	for _, report := range reports {
+		fmt.Fprintf(&list, "#%d · question #%d · %s · %s\n",
+			report.ID, report.QuestionNumber, reportReasonLabel(report.Reason), formatTimestamp(report.CreatedAt))
+		if report.Comment != "" {
+			fmt.Fprintf(&list, "   “%s”\n", truncateRunes(report.Comment, reportCommentPreviewRunes))
+		}
+	}
+	if total > len(reports) {
+		fmt.Fprintf(&list, "\n…and %d more.\n", total-len(reports))
+	}
+	list.WriteString("\nUse /admin report <id> to review one.")
+
+	b.sendMessage(message.Chat.ID, list.String())
+}
+
+// handleAdminReport shows a report together with the question and its current answer and explanation
+func (b *Bot) handleAdminReport(message *tgbotapi.Message, args []string) {
+	if len(args) != 1 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin report <id>")
+		return
+	}
+
+	reportID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The report ID must be a number.")
+		return
+	}
+
+	report, err := b.db.GetReport(reportID)
+	if err != nil {
+		log.Printf("Error getting report %d: %v", reportID, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't retrieve the report.")
+		return
+	}
+	if report == nil {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Report #%d does not exist.", reportID))
+		return
+	}
+
+	status := report.Status
+	switch {
+	case report.ResolvedAt != 0 && report.ResolvedBy == 0:
+		status += fmt.Sprintf(" on %s, as the question was removed from the catalogue", formatTimestamp(report.ResolvedAt))
+	case report.ResolvedAt != 0:
+		status += fmt.Sprintf(" by %d on %s", report.ResolvedBy, formatTimestamp(report.ResolvedAt))
+	}
+	reporter := "deleted user"
+	if report.UserID != 0 {
+		reporter = strconv.FormatInt(report.UserID, 10)
+	}
+
+	text := fmt.Sprintf(`🚩 Report #%d
+
+Question: #%d
+Reason: %s
+Comment: %s
+Reported by: %s on %s
+Status: %s
+`,
+		report.ID, report.QuestionNumber, reportReasonLabel(report.Reason), orDefault(report.Comment, "none"),
+		reporter, formatTimestamp(report.CreatedAt), status)
+
+	question := b.findQuestion(report.QuestionNumber)
+	if question == nil {
+		text += "\nThe question is no longer in the catalogue."
+		b.sendMessage(message.Chat.ID, text)
+		return
+	}
+
+	explanation, _, err := b.db.GetCachedDeepseekResponse(question.Number)
+	if err != nil {
+		log.Printf("Error retrieving cached response: %v", err)
+	}
+	rightAnswer := b.knownRightAnswer(question)
+
+	text += "\n" + question.Question + "\n\n"
+	for i, answer := range question.Answers {
+		marker := "▫️"
+		if i == rightAnswer {
+			marker = "✅"
+		}
+		text += fmt.Sprintf("%s %d. %s\n", marker, i+1, answer)
+	}
+	if question.Image != "" {
+		text += "\nImage: " + question.Image + "\n"
+	}
+	text += "\nExplanation: " + orDefault(truncateRunes(explanation, explanationPreviewRunes), "none") + "\n"
+
+	text += fmt.Sprintf(`
+/admin resolve %d - Close the report
+/admin answer %d <1-%d> - Set the right answer
+/admin explain %d <text> - Replace the explanation`,
+		report.ID, question.Number, len(question.Answers), question.Number)
+
+	b.sendMessage(message.Chat.ID, text)
+}
+
+// handleAdminResolve closes a report
+func (b *Bot) handleAdminResolve(message *tgbotapi.Message, args []string) {
+	if len(args) != 1 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin resolve <id>")
+		return
+	}
+
+	reportID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The report ID must be a number.")
+		return
+	}
+
+	resolved, err := b.db.ResolveReport(reportID, message.From.ID)
+	if err != nil {
+		log.Printf("Error resolving report %d: %v", reportID, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't resolve the report.")
+		return
+	}
+	if !resolved {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Report #%d does not exist or is already resolved.", reportID))
+		return
+	}
+
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("✅ Report #%d resolved.", reportID))
+}
+
+// handleAdminAnswer overrides the right answer of a question. Recorded answers to it are regraded.
+func (b *Bot) handleAdminAnswer(message *tgbotapi.Message, args []string) {
+	if len(args) != 2 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin answer <question> <answer number>")
+		return
+	}
+
+	questionNum, err := strconv.Atoi(args[0])
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The question number must be a number.")
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Question #%d does not exist.", questionNum))
+		return
+	}
+
+	answerNum, err := strconv.Atoi(args[1])
+	if err != nil || answerNum < 1 || answerNum > len(question.Answers) {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("The answer number must be between 1 and %d.", len(question.Answers)))
+		return
+	}
+
+	if err := b.db.OverrideRightAnswer(questionNum, answerNum-1); err != nil {
+		log.Printf("Error overriding right answer of question %d: %v", questionNum, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't update the question.")
+		return
+	}
+
+	log.Printf("Admin %d set the right answer of question %d to %d", message.From.ID, questionNum, answerNum)
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("✅ The right answer of question #%d is now %d. %s\n\nRecorded answers to it have been regraded.",
+		questionNum, answerNum, question.Answers[answerNum-1]))
+}
+
+// handleAdminExplain replaces the explanation of a question with the text of the message
+func (b *Bot) handleAdminExplain(message *tgbotapi.Message, args []string) {
+	if len(args) < 2 {
+		b.sendMessage(message.Chat.ID, "Usage: /admin explain <question> <text>")
+		return
+	}
+
+	questionNum, err := strconv.Atoi(args[0])
+	if err != nil {
+		b.sendMessage(message.Chat.ID, "The question number must be a number.")
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf("Question #%d does not exist.", questionNum))
+		return
+	}
+
+	// Keep the line breaks of the explanation, which strings.Fields would lose
+	_, explanation, _ := strings.Cut(message.Text, "explain")
+	explanation = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(explanation), args[0]))
+
+	if err := b.cacheResponse(questionNum, explanation, b.knownRightAnswer(question)); err != nil {
+		log.Printf("Error overriding explanation of question %d: %v", questionNum, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't update the question.")
+		return
+	}
+
+	log.Printf("Admin %d replaced the explanation of question %d", message.From.ID, questionNum)
+	b.sendMessage(message.Chat.ID, fmt.Sprintf("✅ The explanation of question #%d has been replaced.", questionNum))
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// Presentation modes a user can choose with /settings mode
+const (
+	presentationButtons = "buttons"
+	presentationPoll    = "poll"
+)
+
+// Telegram limits for polls
+const (
+	pollQuestionLimit    = 300
+	pollOptionLimit      = 100
+	pollExplanationLimit = 200
+)
+
+// pollInfo links a sent poll to the question it presents
+type pollInfo struct {
+	chatID         int64
+	userID         int64
+	questionNumber int
+	quiz           bool // Quiz polls grade the answer natively
+	rightAnswer    int
+}
+
+// usesPolls reports whether the user chose to get questions as Telegram polls
+func (b *Bot) usesPolls(userID int64) bool {
+	user, err := b.db.GetUser(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %v", userID, err)
+		return false
+	}
+	return user != nil && user.Presentation == presentationPoll
+}
+
+// sendQuestionPoll presents a question as a Telegram poll. When the right answer is
+// known it is sent as a quiz, so Telegram shows the result and explanation itself.
+// It returns false without sending anything if the question doesn't fit into a poll.
+func (b *Bot) sendQuestionPoll(chatID, userID int64, question *models.Question) bool {
+	questionText := fmt.Sprintf("Question #%d: %s", question.Number, question.Question)
+	if len(question.Answers) < 2 || textLength(questionText) > pollQuestionLimit {
+		return false
+	}
+	for _, answer := range question.Answers {
+		if textLength(answer) > pollOptionLimit {
+			return false
+		}
+	}
+
+	if question.Image != "" {
+		imagePath := b.catalogue.ImagePath(question.Image)
+		b.sendImage(chatID, imagePath, fmt.Sprintf("Question #%d:", question.Number))
+	}
+
+	rightAnswer := b.knownRightAnswer(question)
+	info := pollInfo{
+		chatID:         chatID,
+		userID:         userID,
+		questionNumber: question.Number,
+		quiz:           rightAnswer >= 0 && rightAnswer < len(question.Answers),
+		rightAnswer:    rightAnswer,
+	}
+
+	var sent tgbotapi.Message
+	var err error
+	if info.quiz {
+		poll := tgbotapi.NewPoll(chatID, questionText, question.Answers...)
+		poll.IsAnonymous = false
+		poll.Type = "quiz"
+		poll.CorrectOptionID = int64(rightAnswer)
+		poll.Explanation = truncateRunes(fmt.Sprintf("✅ %s\n\nUse /help for a detailed explanation.", question.Answers[rightAnswer]), pollExplanationLimit)
+		sent, err = b.api.Send(poll)
+	} else {
+		sent, err = b.sendRegularPoll(chatID, questionText, question.Answers)
+	}
+	if err != nil {
+		log.Printf("Error sending poll for question %d: %v", question.Number, err)
+		return false
+	}
+
+	if sent.Poll != nil {
+		// Like a new keyboard closes the earlier ones, only the latest poll of a user in a
+		// chat is answerable, which also keeps unanswered polls from piling up
+		for id, previous := range b.polls {
+			if previous.userID == userID && previous.chatID == chatID {
+				delete(b.polls, id)
+			}
+		}
+		b.polls[sent.Poll.ID] = info
+	}
+	return true
+}
+
+// sendRegularPoll sends a non-anonymous regular poll. SendPollConfig always sets
+// correct_option_id, which only belongs to quizzes, so the request is built by hand.
+func (b *Bot) sendRegularPoll(chatID int64, questionText string, options []string) (tgbotapi.Message, error) {
+	var sent tgbotapi.Message
+
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params["question"] = questionText
+	if err := params.AddInterface("options", options); err != nil {
+		return sent, err
+	}
+	params["is_anonymous"] = "false"
+
+	resp, err := b.api.MakeRequest("sendPoll", params)
+	if err != nil {
+		return sent, err
+	}
+
+	err = json.Unmarshal(resp.Result, &sent)
+	return sent, err
+}
+
+// handlePollAnswer records a user's answer to a question presented as a poll
+func (b *Bot) handlePollAnswer(answer *tgbotapi.PollAnswer) {
+	info, ok := b.polls[answer.PollID]
+	if !ok {
+		log.Printf("Received answer for unknown poll %s", answer.PollID)
+		return
+	}
+	if answer.User.ID != info.userID || len(answer.OptionIDs) == 0 {
+		return
+	}
+
+	// Only the first answer counts
+	delete(b.polls, answer.PollID)
+
+	question := b.findQuestion(info.questionNumber)
+	if question == nil {
+		log.Printf("Question %d of poll %s not found", info.questionNumber, answer.PollID)
+		return
+	}
+
+	answerNum := answer.OptionIDs[0]
+	log.Printf("User %d answered poll for question %d with %d", info.userID, info.questionNumber, answerNum)
+
+	if info.quiz {
+		// Telegram has already shown the result and explanation
+		if err := b.db.SaveUserActivity(info.userID, info.questionNumber, answerNum, answerNum == info.rightAnswer); err != nil {
+			log.Printf("Error saving user activity: %v", err)
+		}
+		return
+	}
+
+	b.processAnswer(info.chatID, info.userID, question, answerNum, time.Now(), nil)
+}
+
+// truncateRunes shortens text to at most limit characters
+func truncateRunes(text string, limit int) string {
+	if runeCount(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit-1]) + "…"
+}
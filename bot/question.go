@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	nextCallbackData      = "next"
+	explainCallbackPrefix = "explain:"
+
+	staleAnswerText     = "This question has already been answered or has expired. Use /next for a new one."
+	notYourQuestionText = "This question was asked to someone else. Use /next for your own one."
+)
+
+// questionMessage identifies the message a question was presented in
+type questionMessage struct {
+	id    int
+	photo bool // Photo messages have a caption instead of a text
+}
+
+// questionText renders a question as HTML
+func questionText(question *models.Question) string {
+	return fmt.Sprintf("<b>Question #%d:</b> %s", question.Number, escapeHTML(question.Question))
+}
+
+// answerKeyboard returns one button per answer option of a presented question
+func answerKeyboard(question *models.Question, presentationID int64) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, answer := range question.Answers {
+		callbackData := fmt.Sprintf("%s%d:%d", callbackPrefix, presentationID, i)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(answer, callbackData)))
+	}
+
+	// If no answers provided, show a default option
+	if len(keyboard) == 0 {
+		callbackData := fmt.Sprintf("%s%d:%d", callbackPrefix, presentationID, 0)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Not sure (no options provided)", callbackData)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// resultKeyboard returns the buttons shown below an answered question
+func resultKeyboard(question *models.Question) *tgbotapi.InlineKeyboardMarkup {
+	number := strconv.Itoa(question.Number)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➡️ Next question", nextCallbackData),
+			tgbotapi.NewInlineKeyboardButtonData("💡 Explain", explainCallbackPrefix+number),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🌐 Translate", translateCallbackPrefix+number),
+			tgbotapi.NewInlineKeyboardButtonData("⚠️ Report a problem", reportCallbackPrefix+number),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✔️ Mark as known", knownCallbackPrefix+number),
+		),
+	)
+	return &keyboard
+}
+
+// answerResultText renders an answered question as HTML, marking the right answer with ✅
+// and a wrong chosen answer with ❌, followed by the verdict
+func answerResultText(question *models.Question, answerNum, rightAnswer int, verdict string) string {
+	var text strings.Builder
+	text.WriteString(questionText(question) + "\n")
+
+	for i, answer := range question.Answers {
+		marker := "▫️"
+		switch {
+		case i == rightAnswer:
+			marker = "✅"
+		case i == answerNum && rightAnswer != -1:
+			marker = "❌"
+		case i == answerNum:
+			marker = "👉"
+		}
+		fmt.Fprintf(&text, "\n%s %s", marker, escapeHTML(answer))
+	}
+
+	text.WriteString("\n\n" + escapeHTML(verdict))
+	return text.String()
+}
+
+// updateQuestionMessage replaces the content and buttons of a question message. A nil
+// keyboard removes the buttons. If target is nil or can't be edited, a new message is
+// sent instead. It returns the message now showing the text.
+func (b *Bot) updateQuestionMessage(chatID int64, target *questionMessage, text string, keyboard *tgbotapi.InlineKeyboardMarkup) *questionMessage {
+	if target != nil {
+		var edit tgbotapi.Chattable
+		if target.photo {
+			caption := tgbotapi.NewEditMessageCaption(chatID, target.id, text)
+			caption.ParseMode = tgbotapi.ModeHTML
+			caption.ReplyMarkup = keyboard
+			edit = caption
+		} else {
+			message := tgbotapi.NewEditMessageText(chatID, target.id, text)
+			message.ParseMode = tgbotapi.ModeHTML
+			message.ReplyMarkup = keyboard
+			edit = message
+		}
+
+		_, err := b.api.Send(edit)
+		if err == nil {
+			return target
+		}
+		log.Printf("Error editing question message %d, sending a new one: %v", target.id, err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		log.Printf("Error sending answer result: %v", err)
+		return nil
+	}
+	return &questionMessage{id: sent.MessageID}
+}
+
+// handleExplainCallback sends the explanation of an answered question
+func (b *Bot) handleExplainCallback(callback *tgbotapi.CallbackQuery) {
+	questionNum, err := strconv.Atoi(strings.TrimPrefix(callback.Data, explainCallbackPrefix))
+	if err != nil {
+		log.Printf("Invalid question number in explain callback: %v", err)
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "")
+
+	question := b.findQuestion(questionNum)
+	if question == nil || callback.Message == nil {
+		return
+	}
+
+	b.explainQuestion(callback.Message.Chat.ID, callback.From.ID, question)
+}
+
+// rejectStaleAnswer acknowledges a tap on an answer button that is no longer valid
+// and removes the buttons from its message
+func (b *Bot) rejectStaleAnswer(callback *tgbotapi.CallbackQuery, notice string) {
+	b.sendCallbackResponse(callback.ID, notice)
+
+	if callback.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error removing stale answer buttons: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/korjavin/lebentestbot/ai"
+	"github.com/korjavin/lebentestbot/database"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// Kinds of paid AI calls recorded in the usage table
+const (
+	aiKindHelp      = "help"
+	aiKindAnswer    = "answer"
+	aiKindFollowUp  = "followup"
+	aiKindTranslate = "translate"
+)
+
+const usageReportDays = 7
+
+// startOfDay returns the Unix time of the most recent local midnight
+func startOfDay(t time.Time) int64 {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location()).Unix()
+}
+
+// checkAIQuota checks the user's and the global daily quota before a paid AI call.
+// When a quota is exhausted it returns false and a message to show to the user.
+func (b *Bot) checkAIQuota(userID int64) (bool, string) {
+	since := startOfDay(time.Now())
+
+	if b.cfg.AIGlobalDailyLimit > 0 {
+		count, err := b.db.CountAIUsageSince(since)
+		if err != nil {
+			log.Printf("Error counting global AI usage: %v", err)
+		} else if count >= b.cfg.AIGlobalDailyLimit {
+			log.Printf("Global AI quota of %d calls exhausted", b.cfg.AIGlobalDailyLimit)
+			return false, "The AI assistant has reached its daily limit. Cached explanations are still available, please try again tomorrow."
+		}
+	}
+
+	if b.cfg.AIUserDailyLimit > 0 {
+		count, err := b.db.CountUserAIUsageSince(userID, since)
+		if err != nil {
+			log.Printf("Error counting AI usage for user %d: %v", userID, err)
+		} else if count >= b.cfg.AIUserDailyLimit {
+			log.Printf("User %d exhausted their AI quota of %d calls", userID, b.cfg.AIUserDailyLimit)
+			return false, fmt.Sprintf("You've used all %d AI requests for today. Cached explanations are still available, and your quota resets at midnight.", b.cfg.AIUserDailyLimit)
+		}
+	}
+
+	return true, ""
+}
+
+// recordAIUsage stores the tokens and cost of a paid AI call made on behalf of a user.
+// Calls that failed without using any tokens, e.g. during an API outage, aren't
+// recorded, so that they don't count towards the quotas.
+func (b *Bot) recordAIUsage(userID int64, questionNumber int, kind string, usage ai.Usage) {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return
+	}
+
+	err := b.db.SaveAIUsage(models.AIUsage{
+		UserID:           userID,
+		QuestionNumber:   questionNumber,
+		Kind:             kind,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             usage.Cost(),
+		Timestamp:        time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("Error saving AI usage for user %d: %v", userID, err)
+	}
+}
+
+// UsageReport summarizes the AI spend per day and of the top users over the last days
+func UsageReport(db *database.DB, now time.Time) (string, error) {
+	since := startOfDay(now.AddDate(0, 0, -(usageReportDays - 1)))
+
+	days, err := db.GetAIUsageByDay(since)
+	if err != nil {
+		return "", err
+	}
+
+	users, err := db.GetAIUsageByUser(since, 10)
+	if err != nil {
+		return "", err
+	}
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "💰 AI usage for the last %d days\n\nPer day:\n", usageReportDays)
+	if len(days) == 0 {
+		report.WriteString("No AI calls recorded.\n")
+	}
+	for _, d := range days {
+		fmt.Fprintf(&report, "%s: %d calls, %d tokens, $%.4f\n", d.Day, d.Calls, d.Tokens, d.Cost)
+	}
+
+	report.WriteString("\nTop users:\n")
+	if len(users) == 0 {
+		report.WriteString("No AI calls recorded.\n")
+	}
+	for _, u := range users {
+		fmt.Fprintf(&report, "User %d: %d calls, %d tokens, $%.4f\n", u.UserID, u.Calls, u.Tokens, u.Cost)
+	}
+
+	return strings.TrimSuffix(report.String(), "\n"), nil
+}
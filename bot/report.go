@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	reportCallbackPrefix = "report:"
+	reportActionSkip     = "skip"
+
+	reportCommentMaxRunes = 1000
+)
+
+// reportReason is a reason a user can pick when reporting a problem
+type reportReason struct {
+	code  string
+	label string
+}
+
+// reportReasons are the reasons offered when reporting a problem, in button order
+var reportReasons = []reportReason{
+	{models.ReportWrongAnswer, "❌ Wrong answer"},
+	{models.ReportBadTranslation, "🌐 Bad translation"},
+	{models.ReportMissingImage, "🖼 Missing image"},
+	{models.ReportTypo, "✏️ Typo"},
+}
+
+// reportReasonLabel returns the button label of a reason code
+func reportReasonLabel(code string) string {
+	for _, reason := range reportReasons {
+		if reason.code == code {
+			return reason.label
+		}
+	}
+	return orDefault(code, "no reason given")
+}
+
+// handleReportCallback handles the report flow: "report:<question>" asks for the reason,
+// "report:<question>:<reason>" records the report and "report:skip:<id>" declines to comment
+func (b *Bot) handleReportCallback(callback *tgbotapi.CallbackQuery) {
+	if callback.Message == nil {
+		b.sendCallbackResponse(callback.ID, "")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, reportCallbackPrefix), ":")
+	if parts[0] == reportActionSkip {
+		b.skipReportComment(callback)
+		return
+	}
+
+	question := b.findReportedQuestion(parts[0])
+	if question == nil {
+		b.sendCallbackResponse(callback.ID, "Sorry, this question is no longer available.")
+		return
+	}
+
+	switch len(parts) {
+	case 1:
+		b.askReportReason(callback, question)
+	case 2:
+		b.createReport(callback, question, parts[1])
+	default:
+		log.Printf("Invalid report callback format: %s", callback.Data)
+		b.sendCallbackResponse(callback.ID, "")
+	}
+}
+
+// findReportedQuestion returns the question with the number from the callback data, or nil
+func (b *Bot) findReportedQuestion(number string) *models.Question {
+	questionNum, err := strconv.Atoi(number)
+	if err != nil {
+		log.Printf("Invalid question number in report callback: %s", number)
+		return nil
+	}
+	return b.findQuestion(questionNum)
+}
+
+// askReportReason asks the user what is wrong with the question
+func (b *Bot) askReportReason(callback *tgbotapi.CallbackQuery, question *models.Question) {
+	b.sendCallbackResponse(callback.ID, "")
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, reason := range reportReasons {
+		data := fmt.Sprintf("%s%d:%s", reportCallbackPrefix, question.Number, reason.code)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(reason.label, data)))
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, fmt.Sprintf("What's wrong with question #%d?", question.Number))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending report reasons: %v", err)
+	}
+}
+
+// createReport records the report and offers to add a comment with the next message
+func (b *Bot) createReport(callback *tgbotapi.CallbackQuery, question *models.Question, reason string) {
+	valid := false
+	for _, r := range reportReasons {
+		valid = valid || r.code == reason
+	}
+	if !valid {
+		log.Printf("Invalid report reason in callback: %s", callback.Data)
+		b.sendCallbackResponse(callback.ID, "")
+		return
+	}
+
+	userID := callback.From.ID
+	reportID, err := b.db.CreateReport(userID, question.Number, reason)
+	if err != nil {
+		log.Printf("Error saving report about question %d: %v", question.Number, err)
+		b.sendCallbackResponse(callback.ID, "Sorry, I couldn't save your report. Please try again later.")
+		return
+	}
+
+	log.Printf("User %d reported question %d: %s", userID, question.Number, reason)
+	b.sendCallbackResponse(callback.ID, "Thanks for reporting!")
+
+	// The next text message is taken as the comment, unless the user skips it
+	b.reportComments[userID] = reportID
+
+	text := fmt.Sprintf("Thanks for reporting question #%d (%s)!\n\nIf you like, describe the problem in your next message. Otherwise tap Skip.",
+		question.Number, reportReasonLabel(reason))
+	skip := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Skip", fmt.Sprintf("%s%s:%d", reportCallbackPrefix, reportActionSkip, reportID)),
+	))
+	edit := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID, text, skip)
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error editing report message: %v", err)
+	}
+}
+
+// skipReportComment closes the report flow without a comment
+func (b *Bot) skipReportComment(callback *tgbotapi.CallbackQuery) {
+	reportID, err := strconv.ParseInt(strings.TrimPrefix(callback.Data, reportCallbackPrefix+reportActionSkip+":"), 10, 64)
+	if err != nil {
+		log.Printf("Invalid report ID in callback: %s", callback.Data)
+	}
+	if err == nil && b.reportComments[callback.From.ID] == reportID {
+		delete(b.reportComments, callback.From.ID)
+	}
+
+	b.sendCallbackResponse(callback.ID, "")
+	b.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, "Thanks for reporting! We'll look into this question.")
+}
+
+// handleReportComment adds a text message to the report waiting for a comment.
+// It returns false if the user has no such report.
+func (b *Bot) handleReportComment(message *tgbotapi.Message) bool {
+	userID := message.From.ID
+	reportID, ok := b.reportComments[userID]
+	if !ok {
+		return false
+	}
+	delete(b.reportComments, userID)
+
+	comment := truncateRunes(strings.TrimSpace(message.Text), reportCommentMaxRunes)
+	if err := b.db.SetReportComment(reportID, userID, comment); err != nil {
+		log.Printf("Error saving comment of report %d: %v", reportID, err)
+		b.sendMessage(message.Chat.ID, "Sorry, I couldn't save your comment. Your report was still recorded.")
+		return true
+	}
+
+	b.sendMessage(message.Chat.ID, "Thanks, I've added your comment to the report. We'll look into this question.")
+	return true
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// scheduler runs delayed functions keyed by session, e.g. the countdown of a
+// timed question. Scheduling a key again replaces its pending function, and a
+// cancelled function is guaranteed not to start. It is safe for concurrent use.
+type scheduler struct {
+	mu     sync.Mutex
+	nextID uint64
+	timers map[string]scheduledTimer
+}
+
+// scheduledTimer is a pending function of the scheduler
+type scheduledTimer struct {
+	id    uint64
+	timer *time.Timer
+}
+
+// newScheduler creates an empty scheduler
+func newScheduler() *scheduler {
+	return &scheduler{timers: make(map[string]scheduledTimer)}
+}
+
+// Schedule runs fn after delay unless the key is cancelled or scheduled again first
+func (s *scheduler) Schedule(key string, delay time.Duration, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.timers[key]; ok {
+		existing.timer.Stop()
+	}
+
+	s.nextID++
+	id := s.nextID
+	s.timers[key] = scheduledTimer{
+		id: id,
+		timer: time.AfterFunc(delay, func() {
+			// The timer may have fired just as it was cancelled or replaced
+			s.mu.Lock()
+			current, ok := s.timers[key]
+			if !ok || current.id != id {
+				s.mu.Unlock()
+				return
+			}
+			delete(s.timers, key)
+			s.mu.Unlock()
+
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Recovered from panic in scheduled function %s: %v", key, r)
+				}
+			}()
+			fn()
+		}),
+	}
+}
+
+// Cancel stops the pending function of the key. It reports whether a function was pending.
+func (s *scheduler) Cancel(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.timers[key]
+	if !ok {
+		return false
+	}
+	existing.timer.Stop()
+	delete(s.timers, key)
+	return true
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	practiceCallbackPrefix = "practice:"
+
+	searchResultLimit      = 5
+	searchSnippetLength    = 120
+	searchUsageText        = "Usage: /search <words>\n\nFor example: /search Bundestag Wahl"
+	searchNoResultsMessage = "No questions match your search. Try other words or fewer of them."
+)
+
+// handleSearchCommand handles the /search command, listing the best matching questions
+// with a button to practise each of them
+func (b *Bot) handleSearchCommand(message *tgbotapi.Message) {
+	query := strings.TrimSpace(message.CommandArguments())
+	if query == "" {
+		b.sendMessage(message.Chat.ID, searchUsageText)
+		return
+	}
+
+	questions := b.searchIndex.Search(query, searchResultLimit)
+	log.Printf("Search by user %d for %q returned %d questions", message.From.ID, query, len(questions))
+	if len(questions) == 0 {
+		b.sendMessage(message.Chat.ID, searchNoResultsMessage)
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "🔎 Questions matching \"%s\":\n", escapeHTML(query))
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, question := range questions {
+		fmt.Fprintf(&text, "\n%d. <b>#%d</b> %s", i+1, question.Number, escapeHTML(truncateRunes(question.Question, searchSnippetLength)))
+
+		callbackData := practiceCallbackPrefix + strconv.Itoa(question.Number)
+		button := tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Practise #%d", question.Number), callbackData)
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(button))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending search results: %v", err)
+	}
+}
+
+// handlePracticeCallback presents the question chosen from a list, e.g. the search results
+func (b *Bot) handlePracticeCallback(callback *tgbotapi.CallbackQuery) {
+	questionNum, err := strconv.Atoi(strings.TrimPrefix(callback.Data, practiceCallbackPrefix))
+	if err != nil {
+		log.Printf("Invalid question number in practice callback: %v", err)
+		return
+	}
+
+	b.sendCallbackResponse(callback.ID, "")
+
+	if callback.Message == nil {
+		return
+	}
+
+	question := b.findQuestion(questionNum)
+	if question == nil {
+		b.sendMessage(callback.Message.Chat.ID, "Sorry, this question is no longer available.")
+		return
+	}
+
+	b.presentQuestion(callback.Message.Chat.ID, callback.From.ID, question)
+}
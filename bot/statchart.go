@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/chart"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	statChartDays   = 30  // Days shown in the charts over time
+	statChartWidth  = 900 // Pixels
+	statPanelHeight = 320 // Pixels of each chart over time
+
+	// The test is passed with 17 of its 33 questions answered right
+	passMark = 17.0 / 33
+)
+
+// sendStatChart sends a user's statistics as a chart image
+func (b *Bot) sendStatChart(chatID, userID int64) {
+	activities, err := b.db.GetUserActivity(userID)
+	if err != nil {
+		log.Printf("Error getting activity of user %d for the statistics chart: %v", userID, err)
+		return
+	}
+	if len(activities) == 0 {
+		return
+	}
+
+	data, err := renderStatChart(activities, b.questions(), time.Now())
+	if err != nil {
+		log.Printf("Error rendering statistics chart of user %d: %v", userID, err)
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "statistics.png", Bytes: data})
+	if _, err := b.api.Send(photo); err != nil {
+		log.Printf("Error sending statistics chart: %v", err)
+	}
+}
+
+// renderStatChart draws the accuracy of quiz answers per day and the questions answered
+// per day over the last days, and how many questions of each topic the user has mastered.
+// A question counts as mastered if its last answer or flashcard rating was right.
+func renderStatChart(activities []models.UserActivity, questions []models.Question, now time.Time) ([]byte, error) {
+	year, month, day := now.Date()
+	first := time.Date(year, month, day-statChartDays+1, 0, 0, 0, 0, now.Location())
+
+	labels := make([]string, statChartDays)
+	for i := range labels {
+		labels[i] = first.AddDate(0, 0, i).Format("Jan 2")
+	}
+
+	answered := make([]float64, statChartDays)
+	quizAnswers := make([]float64, statChartDays)
+	quizCorrect := make([]float64, statChartDays)
+	lastCorrect := make(map[int]bool) // Question number -> whether the last answer was right
+	for _, activity := range activities {
+		lastCorrect[activity.QuestionNumber] = activity.Correct
+
+		answeredAt := time.Unix(activity.Timestamp, 0).In(now.Location())
+		if answeredAt.Before(first) {
+			continue
+		}
+		// Calendar days rather than 24 hours, which differ at daylight saving changes
+		y, m, d := answeredAt.Date()
+		index := int(math.Round(time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Sub(first).Hours() / 24))
+		if index >= statChartDays {
+			continue
+		}
+		answered[index]++
+		if activity.Kind == models.ActivityQuiz {
+			quizAnswers[index]++
+			if activity.Correct {
+				quizCorrect[index]++
+			}
+		}
+	}
+
+	accuracy := make([]float64, statChartDays)
+	for i := range accuracy {
+		accuracy[i] = math.NaN()
+		if quizAnswers[i] > 0 {
+			accuracy[i] = quizCorrect[i] / quizAnswers[i] * 100
+		}
+	}
+
+	mastery := chart.StackedBarChart{
+		Title: "Mastery per topic",
+		Segments: []chart.Segment{
+			{Label: "Mastered", Color: chart.Green},
+			{Label: "Practising", Color: chart.Orange},
+			{Label: "Not answered yet", Color: chart.Gray},
+		},
+	}
+	rows := make(map[string]*chart.StackedRow)
+	var topics []string
+	for _, q := range questions {
+		topic := orDefault(q.Topic, orDefault(q.Category, "Other"))
+		row, ok := rows[topic]
+		if !ok {
+			row = &chart.StackedRow{Label: topic, Values: make([]float64, len(mastery.Segments))}
+			rows[topic] = row
+			topics = append(topics, topic)
+		}
+		correct, ok := lastCorrect[q.Number]
+		switch {
+		case ok && correct:
+			row.Values[0]++
+		case ok:
+			row.Values[1]++
+		default:
+			row.Values[2]++
+		}
+	}
+	for _, topic := range topics {
+		row := rows[topic]
+		row.Note = fmt.Sprintf("%.0f/%.0f", row.Values[0], row.Values[0]+row.Values[1]+row.Values[2])
+		mastery.Rows = append(mastery.Rows, *row)
+	}
+
+	canvas := chart.NewCanvas(statChartWidth, 2*statPanelHeight+mastery.Height())
+	chart.LineChart{
+		Title:          fmt.Sprintf("Accuracy per day (last %d days)", statChartDays),
+		Labels:         labels,
+		Values:         accuracy,
+		Max:            100,
+		Format:         func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+		Color:          chart.Blue,
+		Reference:      passMark * 100,
+		ReferenceLabel: "pass mark",
+	}.Draw(canvas, image.Rect(0, 0, statChartWidth, statPanelHeight))
+	chart.BarChart{
+		Title:  "Questions answered per day",
+		Labels: labels,
+		Values: answered,
+		Color:  chart.Blue,
+	}.Draw(canvas, image.Rect(0, statPanelHeight, statChartWidth, 2*statPanelHeight))
+	mastery.Draw(canvas, image.Rect(0, 2*statPanelHeight, statChartWidth, 2*statPanelHeight+mastery.Height()))
+
+	return canvas.PNG()
+}
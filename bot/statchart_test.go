@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/korjavin/lebentestbot/chart"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+func TestRenderStatChart(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	questions := []models.Question{
+		{Number: 1, Category: "Nationwide", Topic: "Politik in der Demokratie"},
+		{Number: 2, Category: "Nationwide", Topic: "Politik in der Demokratie"},
+		{Number: 3, Category: "Nationwide", Topic: "Geschichte und Verantwortung"},
+		{Number: 4, Category: "Nationwide"},
+	}
+
+	tests := []struct {
+		name       string
+		activities []models.UserActivity
+		questions  []models.Question
+		topics     int
+	}{
+		{"no history and no questions", nil, nil, 0},
+		{"no history", nil, questions, 3},
+		{"flashcards only, so no accuracy", []models.UserActivity{
+			{QuestionNumber: 1, Correct: true, Kind: models.ActivityFlashcard, Timestamp: now.Unix()},
+			{QuestionNumber: 3, Kind: models.ActivityFlashcard, Timestamp: now.AddDate(0, 0, -3).Unix()},
+		}, questions, 3},
+		{"quiz answers older than the chart", []models.UserActivity{
+			{QuestionNumber: 2, Correct: true, Kind: models.ActivityQuiz, Timestamp: now.AddDate(0, -3, 0).Unix()},
+		}, questions, 3},
+		{"answers today", []models.UserActivity{
+			{QuestionNumber: 1, Correct: true, Kind: models.ActivityQuiz, Timestamp: now.Unix()},
+			{QuestionNumber: 4, Kind: models.ActivityQuiz, Timestamp: now.Unix()},
+		}, questions, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := renderStatChart(tt.activities, tt.questions, now)
+			if err != nil {
+				t.Fatalf("renderStatChart: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decoding the chart: %v", err)
+			}
+
+			// The mastery chart has a row per topic, and questions without one go by category
+			mastery := chart.StackedBarChart{Rows: make([]chart.StackedRow, tt.topics)}
+			if got, want := img.Bounds().Dy(), 2*statPanelHeight+mastery.Height(); got != want {
+				t.Errorf("chart is %d pixels high, want %d for %d topics", got, want, tt.topics)
+			}
+			if got := img.Bounds().Dx(); got != statChartWidth {
+				t.Errorf("chart is %d pixels wide, want %d", got, statChartWidth)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	forgetCallbackPrefix = "forget:"
+	forgetConfirm        = "confirm"
+	forgetCancel         = "cancel"
+
+	privateOnlyText = "For your privacy, please send /%s to me in a private chat."
+)
+
+// handleMyDataCommand handles the /mydata command, sending the user everything stored about them
+func (b *Bot) handleMyDataCommand(message *tgbotapi.Message) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
+
+	// The export must not end up in a group
+	if !message.Chat.IsPrivate() {
+		b.sendMessage(chatID, fmt.Sprintf(privateOnlyText, cmdMyData))
+		return
+	}
+
+	export, err := b.db.ExportUserData(userID)
+	if err != nil {
+		log.Printf("Error exporting data of user %d: %v", userID, err)
+		b.sendMessage(chatID, "Sorry, I couldn't export your data. Please try again later.")
+		return
+	}
+
+	exportJSON, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding data export of user %d: %v", userID, err)
+		b.sendMessage(chatID, "Sorry, I couldn't export your data. Please try again later.")
+		return
+	}
+
+	// The answer history is also provided as CSV for use in spreadsheets
+	var activityCSV bytes.Buffer
+	writer := csv.NewWriter(&activityCSV)
+	writer.Write([]string{"question_number", "answer_number", "correct", "timestamp", "kind"})
+	for _, activity := range export.Activity {
+		writer.Write([]string{
+			strconv.Itoa(activity.QuestionNumber),
+			strconv.Itoa(activity.AnswerNumber),
+			strconv.FormatBool(activity.Correct),
+			time.Unix(activity.Timestamp, 0).UTC().Format(time.RFC3339),
+			activity.Kind,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Error encoding activity CSV of user %d: %v", userID, err)
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("Here is all the data I store about you: your profile, %d answers and %d AI requests.",
+		len(export.Activity), len(export.AIUsage)))
+
+	b.sendDocument(chatID, "lebentestbot-mydata.json", exportJSON)
+	b.sendDocument(chatID, "lebentestbot-answers.csv", activityCSV.Bytes())
+}
+
+// handleForgetMeCommand handles the /forgetme command, asking the user to confirm the deletion
+func (b *Bot) handleForgetMeCommand(message *tgbotapi.Message) {
+	// In a group, anyone could tap the confirmation button
+	if !message.Chat.IsPrivate() {
+		b.sendMessage(message.Chat.ID, fmt.Sprintf(privateOnlyText, cmdForgetMe))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		"This will permanently delete your profile, settings, answers and statistics. This cannot be undone.\n\nAre you sure?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Yes, delete everything", forgetCallbackPrefix+forgetConfirm),
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", forgetCallbackPrefix+forgetCancel),
+		),
+	)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending forget confirmation: %v", err)
+	}
+}
+
+// handleForgetCallback deletes the user's data once they confirmed it. The confirmation
+// is only offered in private chats, so whoever taps it is the user who asked.
+func (b *Bot) handleForgetCallback(callback *tgbotapi.CallbackQuery) {
+	if callback.Message == nil || !callback.Message.Chat.IsPrivate() {
+		b.sendCallbackResponse(callback.ID, "")
+		return
+	}
+
+	userID := callback.From.ID
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+
+	if strings.TrimPrefix(callback.Data, forgetCallbackPrefix) != forgetConfirm {
+		b.sendCallbackResponse(callback.ID, "Cancelled")
+		b.editMessage(chatID, messageID, "Nothing was deleted.")
+		return
+	}
+
+	if err := b.db.DeleteUserData(userID); err != nil {
+		log.Printf("Error deleting data of user %d: %v", userID, err)
+		b.sendCallbackResponse(callback.ID, "Deletion failed")
+		b.sendMessage(chatID, "Sorry, I couldn't delete your data. Please try again later.")
+		return
+	}
+
+	// Forget the in-memory session as well
+	delete(b.userQuestions, userID)
+	delete(b.recentlyAsked, userID)
+	delete(b.conversations, userID)
+	delete(b.reportComments, userID)
+
+	log.Printf("Deleted all data of user %d", userID)
+	b.sendCallbackResponse(callback.ID, "Deleted")
+	b.editMessage(chatID, messageID, "All your data has been deleted. If you write to me again, I'll start with a fresh profile.")
+}
+
+// sendDocument sends a file generated in memory
+func (b *Bot) sendDocument(chatID int64, name string, data []byte) {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: data})
+	if _, err := b.api.Send(doc); err != nil {
+		log.Printf("Error sending document %s: %v", name, err)
+	}
+}
@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+var (
+	languagePattern     = regexp.MustCompile(`^[a-z]{2}$`)
+	reminderTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+// touchUser records the sender of an update in the users table
+func (b *Bot) touchUser(update *tgbotapi.Update) {
+	from := update.SentFrom()
+	if update.PollAnswer != nil {
+		from = &update.PollAnswer.User
+	}
+	if from == nil || from.IsBot {
+		return
+	}
+
+	// Only private chats identify the chat in which the user can be reached
+	var chatID int64
+	switch {
+	case update.Message != nil && update.Message.Chat.IsPrivate():
+		chatID = update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat.IsPrivate():
+		chatID = update.CallbackQuery.Message.Chat.ID
+	}
+
+	err := b.db.TouchUser(models.User{
+		UserID:       from.ID,
+		ChatID:       chatID,
+		Username:     from.UserName,
+		FirstName:    from.FirstName,
+		LastName:     from.LastName,
+		LanguageCode: from.LanguageCode,
+	})
+	if err != nil {
+		log.Printf("Error recording user %d: %v", from.ID, err)
+	}
+}
+
+// privateChatID returns the chat in which a user can be reached privately
+func (b *Bot) privateChatID(userID int64) int64 {
+	user, err := b.db.GetUser(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %v", userID, err)
+	}
+	if user == nil || user.ChatID == 0 {
+		// In private chats the chat ID equals the user ID
+		return userID
+	}
+	return user.ChatID
+}
+
+// handleSettingsCommand handles the /settings command for viewing and changing preferences
+func (b *Bot) handleSettingsCommand(message *tgbotapi.Message) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
+	args := strings.Fields(message.Text)[1:]
+
+	if len(args) == 0 {
+		b.sendSettings(chatID, userID)
+		return
+	}
+
+	value := strings.Join(args[1:], " ")
+
+	var err error
+	switch args[0] {
+	case "language":
+		value = strings.ToLower(value)
+		if !languagePattern.MatchString(value) {
+			b.sendMessage(chatID, "Please give a two-letter language code, for example: /settings language en")
+			return
+		}
+		err = b.db.SetUserLanguage(userID, value)
+	case "state":
+		state, ok := models.FindState(value)
+		if !ok {
+			codes := make([]string, len(models.States))
+			for i, s := range models.States {
+				codes[i] = s.Code
+			}
+			b.sendMessage(chatID, "Please give a federal state by name or code: "+strings.Join(codes, ", "))
+			return
+		}
+		err = b.db.SetUserBundesland(userID, state.Name)
+	case "reminder":
+		if value == "off" {
+			value = ""
+		} else if !reminderTimePattern.MatchString(value) {
+			b.sendMessage(chatID, "Please give a time as HH:MM, for example: /settings reminder 19:30, or /settings reminder off")
+			return
+		}
+		err = b.db.SetUserReminderTime(userID, value)
+	case "mode":
+		if value != presentationButtons && value != presentationPoll {
+			b.sendMessage(chatID, "Please choose how questions are shown: /settings mode buttons, or /settings mode poll")
+			return
+		}
+		err = b.db.SetUserPresentation(userID, value)
+	case "known":
+		if value != "reset" {
+			b.sendMessage(chatID, "Use /settings known reset to practise the questions you marked as known again.")
+			return
+		}
+		_, err = b.db.ResetKnownQuestions(userID)
+	default:
+		b.sendSettings(chatID, userID)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error updating settings for user %d: %v", userID, err)
+		b.sendMessage(chatID, "Sorry, I couldn't save your settings. Please try again later.")
+		return
+	}
+
+	b.sendSettings(chatID, userID)
+}
+
+// sendSettings shows the user's current preferences
+func (b *Bot) sendSettings(chatID, userID int64) {
+	user, err := b.db.GetUser(userID)
+	if err != nil || user == nil {
+		log.Printf("Error getting user %d: %v", userID, err)
+		b.sendMessage(chatID, "Sorry, I couldn't load your settings. Please try again later.")
+		return
+	}
+
+	language := user.Language
+	if language == "" {
+		language = fmt.Sprintf("not set (Telegram: %s)", orDefault(user.LanguageCode, "unknown"))
+	}
+
+	known, err := b.db.GetKnownQuestions(userID)
+	if err != nil {
+		log.Printf("Error getting known questions of user %d: %v", userID, err)
+	}
+
+	settingsText := fmt.Sprintf(`⚙️ Your Settings:
+
+Language: %s
+Federal state: %s
+Daily reminder: %s
+Questions shown as: %s
+Questions marked as known: %d
+
+Change them with:
+/settings language <code>
+/settings state <name or code>
+/settings reminder <HH:MM or off>
+/settings mode <buttons or poll>
+/settings known reset`,
+		language, orDefault(user.Bundesland, "not set"), orDefault(user.ReminderTime, "off"),
+		orDefault(user.Presentation, presentationButtons), len(known))
+
+	b.sendMessage(chatID, settingsText)
+}
+
+// orDefault returns value, or def if value is empty
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+// Package catalogue loads the question bank from the assets directory and
+// reloads it when the file changes
+package catalogue
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// QuestionsFile is the name of the question file in the assets directory
+const QuestionsFile = "questions.json"
+
+// Catalogue is the question bank of an assets directory. A reload replaces the
+// questions as a whole, so slices returned by Questions are never modified.
+// It is safe for concurrent use.
+type Catalogue struct {
+	dir string
+
+	mu        sync.RWMutex
+	version   string
+	questions []models.Question
+	modTime   time.Time // Of the question file when it was last read
+	size      int64
+}
+
+// Load loads and validates the question file of an assets directory.
+// It returns the warnings found, or a *ValidationError if the file can't be used.
+func Load(dir string) (*Catalogue, []Issue, error) {
+	c := &Catalogue{dir: dir}
+	warnings, err := c.Reload()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, warnings, nil
+}
+
+// Reload reads the question file again. If it can't be read or is invalid, the
+// questions loaded before are kept and the error is returned.
+func (c *Catalogue) Reload() ([]Issue, error) {
+	path := c.Path()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// A broken file is not read again until it changes, even if it is rejected
+	c.mu.Lock()
+	c.modTime = info.ModTime()
+	c.size = info.Size()
+	c.mu.Unlock()
+
+	file, issues := Parse(data, c.dir)
+	if HasErrors(issues) {
+		var invalid []Issue
+		for _, issue := range issues {
+			if issue.Severity == SeverityError {
+				invalid = append(invalid, issue)
+			}
+		}
+		return nil, &ValidationError{Path: path, Issues: invalid}
+	}
+
+	c.mu.Lock()
+	c.version = file.Version
+	c.questions = file.Questions
+	c.mu.Unlock()
+
+	return issues, nil
+}
+
+// Changed reports whether the question file was modified since it was last read
+func (c *Catalogue) Changed() bool {
+	info, err := os.Stat(c.Path())
+	if err != nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !info.ModTime().Equal(c.modTime) || info.Size() != c.size
+}
+
+// Questions returns the questions, sorted by number. The slice must not be modified.
+func (c *Catalogue) Questions() []models.Question {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.questions
+}
+
+// Version returns the version of the catalogue, or "" if the file has none
+func (c *Catalogue) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Path returns the path of the question file
+func (c *Catalogue) Path() string {
+	return filepath.Join(c.dir, QuestionsFile)
+}
+
+// ImagePath returns the path of an image of a question
+func (c *Catalogue) ImagePath(image string) string {
+	return filepath.Join(c.dir, image)
+}
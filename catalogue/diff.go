@@ -0,0 +1,117 @@
+package catalogue
+
+import (
+	"strings"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// Kinds of catalogue changes
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change is a difference between two versions of a catalogue
+type Change struct {
+	Kind     string
+	Number   int
+	Question string   // Text of the question in the newer version, or the older one if it was removed
+	Fields   []string // Fields that differ, for modified questions
+}
+
+// Diff compares two versions of a catalogue by question number
+func Diff(old, new []models.Question) []Change {
+	oldByNumber := make(map[int]models.Question, len(old))
+	for _, q := range old {
+		oldByNumber[q.Number] = q
+	}
+	newNumbers := make(map[int]bool, len(new))
+
+	var changes []Change
+	for _, q := range new {
+		newNumbers[q.Number] = true
+		previous, ok := oldByNumber[q.Number]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Number: q.Number, Question: q.Question})
+			continue
+		}
+		if fields := changedFields(previous, q); len(fields) > 0 {
+			changes = append(changes, Change{Kind: ChangeModified, Number: q.Number, Question: q.Question, Fields: fields})
+		}
+	}
+
+	for _, q := range old {
+		if !newNumbers[q.Number] {
+			changes = append(changes, Change{Kind: ChangeRemoved, Number: q.Number, Question: q.Question})
+		}
+	}
+
+	return changes
+}
+
+// Move is a question that was renumbered, as found by its ID
+type Move struct {
+	ID       string
+	From     int
+	To       int
+	Question string // Text of the question in the newer version
+}
+
+// Moves returns the questions of the newer version of a catalogue that had another number
+// in the older one, in the order of their new numbers
+func Moves(old, new []models.Question) []Move {
+	oldNumbers := make(map[string]int, len(old))
+	for _, q := range old {
+		oldNumbers[q.ID] = q.Number
+	}
+
+	var moves []Move
+	for _, q := range new {
+		if from, ok := oldNumbers[q.ID]; ok && from != q.Number {
+			moves = append(moves, Move{ID: q.ID, From: from, To: q.Number, Question: q.Question})
+		}
+	}
+	return moves
+}
+
+// changedFields returns the JSON names of the fields that differ between two versions of a question.
+// Differences in whitespace are ignored, as they depend on the PDF extraction.
+func changedFields(old, new models.Question) []string {
+	var fields []string
+	if old.ID != new.ID {
+		fields = append(fields, "ID")
+	}
+	if normalizeSpace(old.Question) != normalizeSpace(new.Question) {
+		fields = append(fields, "Question")
+	}
+	if len(old.Answers) != len(new.Answers) {
+		fields = append(fields, "Answers")
+	} else {
+		for i := range old.Answers {
+			if normalizeSpace(old.Answers[i]) != normalizeSpace(new.Answers[i]) {
+				fields = append(fields, "Answers")
+				break
+			}
+		}
+	}
+	if old.RightAnswer != new.RightAnswer {
+		fields = append(fields, "Right answer")
+	}
+	if old.Category != new.Category {
+		fields = append(fields, "Category")
+	}
+	if old.Topic != new.Topic {
+		fields = append(fields, "Topic")
+	}
+	if old.Image != new.Image {
+		fields = append(fields, "Image")
+	}
+	return fields
+}
+
+// normalizeSpace collapses runs of whitespace into single spaces
+func normalizeSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
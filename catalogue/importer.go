@@ -0,0 +1,457 @@
+package catalogue
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Images extracted with "pdfimages -j"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// StatesFile is the name of the file with the questions of the federal states in the assets directory
+const StatesFile = "bundeslands.json"
+
+// nationwideCategory is the category of the questions asked in every federal state
+const nationwideCategory = "Nationwide"
+
+// Lines of the official catalogue ("Gesamtfragenkatalog") as extracted with pdftotext
+var (
+	taskHeading    = regexp.MustCompile(`^Aufgabe\s+(\d+)$`)
+	pageFooter     = regexp.MustCompile(`^Seite\s+\d+\s+von\s+\d+$`)
+	sectionHeading = regexp.MustCompile(`^(Teil\s+[IV]+\b.*|Allgemeine Fragen)$`)
+
+	// "Fragen zum Bundesland Bayern", possibly after "Teil II"
+	stateHeading = regexp.MustCompile(`^(Teil\s+[IV]+\W*)?Fragen\s+(zum|für\s+das)\s+Bundesland\s+(.+)$`)
+
+	// pdfimages -p names the images <prefix>-<page>-<number>.<format>
+	extractedImage = regexp.MustCompile(`-(\d+)-(\d+)\.[a-z0-9]+$`)
+)
+
+// Check boxes in front of the answers. The PDF uses symbol fonts, so depending on the
+// extraction tool they come out as Unicode boxes or as private use characters.
+const (
+	uncheckedBoxes = "□☐○\uf0a3\uf0a8\uf06f"
+	checkedBoxes   = "■☒☑✓✔●\uf0fe\uf078"
+)
+
+// importEntry is a question of the imported catalogue with where it was found
+type importEntry struct {
+	question models.Question
+	state    *models.State // nil for nationwide questions
+	page     int
+	images   []string // Extracted image files of the question, in order
+}
+
+// Import is a question catalogue read from the text of the official PDF
+type Import struct {
+	entries []*importEntry
+	Notes   []string // Problems found while importing that need a manual check
+}
+
+// ParseText reads the text of the official catalogue as extracted with "pdftotext",
+// which separates pages with form feeds. Nationwide questions keep the number of their
+// "Aufgabe". The "Aufgabe" numbers of the federal states start at 1 for every state, so
+// their questions are numbered through in order of appearance instead: Baden-Württemberg
+// 1 to 10, Bayern 11 to 20 and so on. A checked box marks the right answer; without one
+// the right answer is -1. Every question gets an ID derived from its content, which
+// CarryOver replaces with the ID of the matching question of the current catalogue.
+func ParseText(text string) *Import {
+	imp := &Import{}
+
+	var current *importEntry
+	var state *models.State
+	var answerPage int      // Page of the last answer line, for joining wrapped answers
+	var marked map[int]bool // Answers of the current question with a checked box
+	var stateQuestions int
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		q := &current.question
+		q.Question = strings.Join(strings.Fields(q.Question), " ")
+		q.RightAnswer = -1
+		switch {
+		case q.Question == "" || len(q.Answers) == 0:
+			imp.note(current, "has no question text or no answers and was left out")
+			current = nil
+			return
+		case len(marked) == 1:
+			for i := range marked {
+				q.RightAnswer = i
+			}
+		case len(marked) > 1:
+			imp.note(current, "has %d answers marked as right, so the right answer was left unknown", len(marked))
+		}
+		q.ID = contentID(*q)
+		imp.entries = append(imp.entries, current)
+		current = nil
+	}
+
+	for pageIndex, page := range strings.Split(text, "\f") {
+		pageNum := pageIndex + 1
+		for _, line := range strings.Split(page, "\n") {
+			line = strings.TrimSpace(line)
+			if match := stateHeading.FindStringSubmatch(line); match != nil {
+				if found := stateNamed(match[3]); found != nil {
+					finish()
+					state = found
+					continue
+				}
+			}
+			if line == "" || pageFooter.MatchString(line) || sectionHeading.MatchString(line) {
+				continue
+			}
+
+			if match := taskHeading.FindStringSubmatch(line); match != nil {
+				finish()
+				number, _ := strconv.Atoi(match[1])
+				current = &importEntry{state: state, page: pageNum}
+				current.question.Category = nationwideCategory
+				current.question.Number = number
+				if state != nil {
+					stateQuestions++
+					current.question.Number = stateQuestions
+					current.question.Category = state.Name
+				}
+				marked = make(map[int]bool)
+				continue
+			}
+
+			if current == nil {
+				continue
+			}
+
+			q := &current.question
+			if box, rest, ok := cutCheckBox(line); ok {
+				if strings.ContainsRune(checkedBoxes, box) {
+					marked[len(q.Answers)] = true
+				}
+				q.Answers = append(q.Answers, rest)
+				answerPage = pageNum
+				continue
+			}
+
+			if len(q.Answers) == 0 {
+				q.Question += " " + line
+			} else if answerPage == pageNum {
+				// A long answer wrapped onto the next line
+				q.Answers[len(q.Answers)-1] += " " + line
+			}
+		}
+	}
+	finish()
+
+	return imp
+}
+
+// stateNamed returns the federal state whose name starts the text, or nil. Longer names
+// are tried first, so that "Sachsen-Anhalt" isn't taken for "Sachsen".
+func stateNamed(text string) *models.State {
+	states := make([]models.State, len(models.States))
+	copy(states, models.States)
+	sort.Slice(states, func(i, j int) bool {
+		return len(states[i].Name) > len(states[j].Name)
+	})
+	for i := range states {
+		if strings.HasPrefix(text, states[i].Name) {
+			return &states[i]
+		}
+	}
+	return nil
+}
+
+// cutCheckBox splits an answer line into its check box and the answer text
+func cutCheckBox(line string) (rune, string, bool) {
+	for _, box := range uncheckedBoxes + checkedBoxes {
+		if rest, ok := strings.CutPrefix(line, string(box)); ok {
+			return box, strings.TrimSpace(rest), true
+		}
+	}
+	return 0, "", false
+}
+
+// note records a problem with a question
+func (imp *Import) note(entry *importEntry, format string, args ...interface{}) {
+	imp.Notes = append(imp.Notes, entry.String()+" "+fmt.Sprintf(format, args...))
+}
+
+// String names the question for notes
+func (e *importEntry) String() string {
+	if e.state != nil {
+		return fmt.Sprintf("%s question #%d (page %d)", e.state.Name, e.question.Number, e.page)
+	}
+	return fmt.Sprintf("Question #%d (page %d)", e.question.Number, e.page)
+}
+
+// Nationwide returns the imported nationwide questions
+func (imp *Import) Nationwide() []models.Question {
+	return imp.questions(false)
+}
+
+// States returns the imported questions of the federal states
+func (imp *Import) States() []models.Question {
+	return imp.questions(true)
+}
+
+// questions returns the nationwide or the state questions
+func (imp *Import) questions(states bool) []models.Question {
+	var questions []models.Question
+	for _, entry := range imp.entries {
+		if (entry.state != nil) == states {
+			questions = append(questions, entry.question)
+		}
+	}
+	return questions
+}
+
+// LinkImages assigns the images extracted with "pdfimages -p" into dir to the questions
+// that refer to a picture on the same page. A question with several images, such as
+// the four coats of arms to choose from, gets them combined side by side.
+func (imp *Import) LinkImages(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Images per page, in the order they appear on it
+	type pageImage struct {
+		number int
+		path   string
+	}
+	byPage := make(map[int][]pageImage)
+	for _, file := range files {
+		match := extractedImage.FindStringSubmatch(file.Name())
+		if file.IsDir() || match == nil {
+			continue
+		}
+		page, _ := strconv.Atoi(match[1])
+		number, _ := strconv.Atoi(match[2])
+		byPage[page] = append(byPage[page], pageImage{number, filepath.Join(dir, file.Name())})
+	}
+
+	needing := make(map[int][]*importEntry)
+	for _, entry := range imp.entries {
+		q := &entry.question
+		if imageReference.MatchString(q.Question + "\n" + strings.Join(q.Answers, "\n")) {
+			needing[entry.page] = append(needing[entry.page], entry)
+		}
+	}
+
+	pages := make([]int, 0, len(needing))
+	for page := range needing {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+
+	for _, page := range pages {
+		entries := needing[page]
+		images := byPage[page]
+		sort.Slice(images, func(i, j int) bool { return images[i].number < images[j].number })
+
+		switch {
+		case len(images) == 0:
+			for _, entry := range entries {
+				imp.note(entry, "refers to a picture, but there is no image on its page")
+			}
+		case len(entries) == 1:
+			for _, img := range images {
+				entries[0].images = append(entries[0].images, img.path)
+			}
+		case len(entries) == len(images):
+			for i, entry := range entries {
+				entry.images = []string{images[i].path}
+			}
+		default:
+			for _, entry := range entries {
+				imp.note(entry, "shares page %d and its %d images with other questions, link the image manually", page, len(images))
+			}
+		}
+	}
+
+	for _, entry := range imp.entries {
+		if len(entry.images) > 0 {
+			entry.question.Image = imageName(entry)
+		}
+	}
+	return nil
+}
+
+// imageName returns the path of a question's image in the assets directory
+func imageName(entry *importEntry) string {
+	if entry.state != nil {
+		return fmt.Sprintf("images/state-%s-%03d.png", strings.ToLower(entry.state.Code), entry.question.Number)
+	}
+	return fmt.Sprintf("images/question-%03d.png", entry.question.Number)
+}
+
+// CarryOver keeps what the current catalogue knows about the imported questions. An
+// imported question takes over the ID of the current question it matches, so that the
+// bot moves what it stored about the question along when its number changes: first the
+// question with the same category, text and answers, wherever it is, and else the one
+// with the same number and answers, whose text was only reworded. Matched questions
+// keep their topic, which the PDF doesn't have, and the known right answer if none is marked.
+func (imp *Import) CarryOver(nationwide, states []models.Question) {
+	type currentQuestions struct {
+		byContent map[string]models.Question
+		byNumber  map[int]models.Question
+		ids       map[string]bool // IDs of all current questions
+		claimed   map[string]bool // IDs taken over by an imported question
+	}
+	index := func(questions []models.Question) *currentQuestions {
+		current := &currentQuestions{
+			byContent: make(map[string]models.Question, len(questions)),
+			byNumber:  make(map[int]models.Question, len(questions)),
+			ids:       make(map[string]bool, len(questions)),
+			claimed:   make(map[string]bool),
+		}
+		for _, q := range questions {
+			current.byContent[contentID(q)] = q
+			current.byNumber[q.Number] = q
+			current.ids[q.ID] = true
+		}
+		return current
+	}
+	catalogues := map[bool]*currentQuestions{false: index(nationwide), true: index(states)}
+
+	matched := make(map[*importEntry]bool)
+	carry := func(entry *importEntry, previous models.Question) {
+		q := &entry.question
+		q.ID = previous.ID
+		q.Topic = previous.Topic
+		if q.RightAnswer == -1 && previous.RightAnswer != -1 && sameAnswers(previous.Answers, q.Answers) {
+			q.RightAnswer = previous.RightAnswer
+		}
+		catalogues[entry.state != nil].claimed[previous.ID] = true
+		matched[entry] = true
+	}
+
+	// Unchanged questions first, so that a reworded question can't take over their IDs
+	for _, entry := range imp.entries {
+		current := catalogues[entry.state != nil]
+		if previous, ok := current.byContent[contentID(entry.question)]; ok && !current.claimed[previous.ID] {
+			carry(entry, previous)
+		}
+	}
+	for _, entry := range imp.entries {
+		current := catalogues[entry.state != nil]
+		previous, ok := current.byNumber[entry.question.Number]
+		if !matched[entry] && ok && !current.claimed[previous.ID] && sameAnswers(previous.Answers, entry.question.Answers) {
+			carry(entry, previous)
+		}
+	}
+
+	// A new question must not reuse the ID of a current one, or the bot would take
+	// what it stored about that question for the new one
+	for _, entry := range imp.entries {
+		current := catalogues[entry.state != nil]
+		if matched[entry] || !current.ids[entry.question.ID] {
+			continue
+		}
+		base := entry.question.ID
+		for i := 2; current.ids[entry.question.ID]; i++ {
+			entry.question.ID = fmt.Sprintf("%s-%d", base, i)
+		}
+	}
+}
+
+// contentID derives an ID for a new question from its category, text and answers
+func contentID(q models.Question) string {
+	hash := sha256.New()
+	for _, text := range append([]string{q.Category, q.Question}, q.Answers...) {
+		hash.Write([]byte(normalizeSpace(text)))
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:12]
+}
+
+// WriteImages writes the images linked to the questions into the assets directory
+func (imp *Import) WriteImages(assetsDir string) (int, error) {
+	var written int
+	for _, entry := range imp.entries {
+		if len(entry.images) == 0 {
+			continue
+		}
+
+		img, err := combineImages(entry.images)
+		if err != nil {
+			return written, fmt.Errorf("image of %s: %w", entry, err)
+		}
+
+		path := filepath.Join(assetsDir, entry.question.Image)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, err
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return written, err
+		}
+		err = png.Encode(file, img)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, fmt.Errorf("writing %s: %w", path, err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// combineImages decodes images and places them side by side, top aligned, on white
+func combineImages(paths []string) (image.Image, error) {
+	const gap = 20 // Pixels between the images
+
+	var images []image.Image
+	var width, height int
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s (extract the images with pdfimages -png): %w", path, err)
+		}
+		images = append(images, img)
+		width += img.Bounds().Dx()
+		height = max(height, img.Bounds().Dy())
+	}
+	if len(images) == 1 {
+		return images[0], nil
+	}
+	width += gap * (len(images) - 1)
+
+	combined := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(combined, combined.Bounds(), image.White, image.Point{}, draw.Src)
+	x := 0
+	for _, img := range images {
+		bounds := img.Bounds()
+		draw.Draw(combined, image.Rect(x, 0, x+bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Over)
+		x += bounds.Dx() + gap
+	}
+	return combined, nil
+}
+
+// sameAnswers reports whether two lists of answers are equal, ignoring differences in whitespace
+func sameAnswers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if normalizeSpace(a[i]) != normalizeSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
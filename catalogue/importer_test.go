@@ -0,0 +1,187 @@
+package catalogue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// testCatalogueText is the text of a short catalogue as extracted with pdftotext
+var testCatalogueText = strings.Join([]string{
+	`Teil I
+Allgemeine Fragen
+Aufgabe 1
+Wer wählt den
+Bundestag?
+□ der Bundesrat
+■ das Volk
+□ die Länder
+□ der Bundespräsident
+Aufgabe 2
+Was ist die Hauptstadt?
+` + "\uf0a3" + ` Bonn
+` + "\uf0fe" + ` Berlin
+☐ Hamburg
+☐ München`,
+	`Seite 2 von 3
+Aufgabe 3
+Was garantiert das Grundgesetz?
+☐ die Freiheit der Meinung
+und der Presse
+☒ die Wahlpflicht
+☑ die Kirchensteuer
+☐ den Mindestlohn
+Seite 2 von 3`,
+	`Teil II
+Fragen zum Bundesland Bayern
+Aufgabe 1
+Welches Wappen gehört zu Bayern?
+□ Bild 1
+■ Bild 2
+□ Bild 3
+□ Bild 4
+Fragen zum Bundesland Berlin
+Aufgabe 1
+Wer wählt den Berliner Senat?
+□ das Volk
+■ das Abgeordnetenhaus
+□ der Bundestag
+□ der Bundesrat`,
+}, "\f")
+
+func TestParseText(t *testing.T) {
+	imp := ParseText(testCatalogueText)
+
+	nationwide := imp.Nationwide()
+	if len(nationwide) != 3 {
+		t.Fatalf("Nationwide() returned %d questions, want 3", len(nationwide))
+	}
+
+	first := nationwide[0]
+	if first.Number != 1 || first.Question != "Wer wählt den Bundestag?" || first.Category != nationwideCategory {
+		t.Errorf("question 1 = %+v, want the question text joined over two lines", first)
+	}
+	if first.RightAnswer != 1 || len(first.Answers) != 4 || first.Answers[1] != "das Volk" {
+		t.Errorf("question 1 has answers %q and right answer %d, want das Volk", first.Answers, first.RightAnswer)
+	}
+
+	// The boxes of the symbol font come out as private use characters
+	if second := nationwide[1]; second.RightAnswer != 1 || second.Answers[0] != "Bonn" {
+		t.Errorf("question 2 has answers %q and right answer %d, want Berlin", second.Answers, second.RightAnswer)
+	}
+
+	third := nationwide[2]
+	if third.Answers[0] != "die Freiheit der Meinung und der Presse" {
+		t.Errorf("answer 1 of question 3 = %q, want it joined over two lines", third.Answers[0])
+	}
+	if third.RightAnswer != -1 {
+		t.Errorf("question 3 has two checked boxes but right answer %d, want -1", third.RightAnswer)
+	}
+	if len(imp.Notes) != 1 || !strings.Contains(imp.Notes[0], "Question #3") || !strings.Contains(imp.Notes[0], "2 answers marked") {
+		t.Errorf("notes = %q, want one about the two checked boxes of question 3", imp.Notes)
+	}
+
+	// Every state starts its Aufgabe numbers at 1, so they are numbered through
+	states := imp.States()
+	if len(states) != 2 {
+		t.Fatalf("States() returned %d questions, want 2", len(states))
+	}
+	if states[0].Number != 1 || states[0].Category != "Bayern" || states[0].RightAnswer != 1 {
+		t.Errorf("first state question = %+v, want Bayern #1", states[0])
+	}
+	if states[1].Number != 2 || states[1].Category != "Berlin" || states[1].Question != "Wer wählt den Berliner Senat?" {
+		t.Errorf("second state question = %+v, want Berlin #2", states[1])
+	}
+
+	for _, q := range append(nationwide, states...) {
+		if q.ID != contentID(q) {
+			t.Errorf("question %d has ID %q, want its content ID %q", q.Number, q.ID, contentID(q))
+		}
+	}
+}
+
+func TestCarryOver(t *testing.T) {
+	imp := ParseText(testCatalogueText)
+	imported := imp.Nationwide()
+
+	// The current catalogue has question 1 as #2, question 2 reworded, and a question
+	// whose default ID an imported question would get
+	unchanged := imported[0]
+	unchanged.Number, unchanged.ID, unchanged.Topic = 2, "volk", "Politik"
+	reworded := imported[1]
+	reworded.Question, reworded.ID, reworded.Topic = "Wie heißt die Hauptstadt?", "hauptstadt", "Geschichte"
+	removed := models.Question{Number: 1, ID: imported[2].ID, Question: "Alte Frage", Answers: []string{"a", "b", "c", "d"},
+		Category: nationwideCategory}
+	known := imported[2]
+	known.Number, known.ID, known.RightAnswer = 9, "other", 0
+	known.Answers = []string{"anders", "b", "c", "d"}
+
+	imp.CarryOver([]models.Question{removed, unchanged, reworded, known}, nil)
+	got := imp.Nationwide()
+
+	if got[0].ID != "volk" || got[0].Topic != "Politik" {
+		t.Errorf("question 1 = %+v, want the ID and topic of the unchanged question", got[0])
+	}
+	if got[1].ID != "hauptstadt" || got[1].Topic != "Geschichte" {
+		t.Errorf("question 2 = %+v, want the ID and topic of the reworded question", got[1])
+	}
+	if got[2].ID == imported[2].ID || !strings.HasPrefix(got[2].ID, imported[2].ID) {
+		t.Errorf("question 3 has ID %q, want a new one based on %q", got[2].ID, imported[2].ID)
+	}
+	if got[2].RightAnswer != -1 {
+		t.Errorf("question 3 has right answer %d, want -1 as it matches no current question", got[2].RightAnswer)
+	}
+}
+
+func TestCarryOverKnownRightAnswer(t *testing.T) {
+	imp := ParseText(testCatalogueText)
+	imported := imp.Nationwide()
+
+	// Question 3 has two checked boxes, but the current catalogue knows the right answer
+	current := imported[2]
+	current.ID, current.RightAnswer = "grundgesetz", 0
+	imp.CarryOver([]models.Question{current}, nil)
+
+	if got := imp.Nationwide()[2]; got.ID != "grundgesetz" || got.RightAnswer != 0 {
+		t.Errorf("question 3 = %+v, want the ID and right answer of the current question", got)
+	}
+}
+
+func TestDiffAndMoves(t *testing.T) {
+	old := []models.Question{
+		{Number: 1, ID: "a", Question: "Frage A", Answers: []string{"1", "2"}, RightAnswer: 0},
+		{Number: 2, ID: "b", Question: "Frage B", Answers: []string{"1", "2"}, RightAnswer: 1},
+		{Number: 3, ID: "c", Question: "Frage  C", Answers: []string{"1", "2"}, RightAnswer: 0},
+		{Number: 4, ID: "d", Question: "Frage D", Answers: []string{"1", "2"}},
+	}
+	new := []models.Question{
+		{Number: 1, ID: "b", Question: "Frage B", Answers: []string{"1", "2"}, RightAnswer: 1},
+		{Number: 2, ID: "a", Question: "Frage A", Answers: []string{"1", "2"}, RightAnswer: 0},
+		{Number: 3, ID: "c", Question: "Frage C", Answers: []string{"1", "2 "}, RightAnswer: 0},
+		{Number: 5, ID: "e", Question: "Frage E", Answers: []string{"1", "2"}},
+	}
+
+	changes := Diff(old, new)
+	want := []Change{
+		{Kind: ChangeModified, Number: 1, Fields: []string{"ID", "Question", "Right answer"}},
+		{Kind: ChangeModified, Number: 2, Fields: []string{"ID", "Question", "Right answer"}},
+		{Kind: ChangeAdded, Number: 5},
+		{Kind: ChangeRemoved, Number: 4},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff = %+v, want %d changes", changes, len(want))
+	}
+	for i, change := range changes {
+		if change.Kind != want[i].Kind || change.Number != want[i].Number ||
+			strings.Join(change.Fields, ",") != strings.Join(want[i].Fields, ",") {
+			t.Errorf("change %d = %+v, want %+v", i, change, want[i])
+		}
+	}
+
+	moves := Moves(old, new)
+	if len(moves) != 2 || moves[0] != (Move{ID: "b", From: 2, To: 1, Question: "Frage B"}) ||
+		moves[1] != (Move{ID: "a", From: 1, To: 2, Question: "Frage A"}) {
+		t.Errorf("Moves = %+v, want b from 2 to 1 and a from 1 to 2", moves)
+	}
+}
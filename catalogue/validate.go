@@ -0,0 +1,383 @@
+package catalogue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// Severities of catalogue issues
+const (
+	SeverityError   = "error"   // The catalogue can't be used
+	SeverityWarning = "warning" // The entry is skipped or used as it is
+)
+
+// Codes of catalogue issues, for tools processing the issue list
+const (
+	CodeInvalidJSON     = "invalid_json"
+	CodeInvalidEntry    = "invalid_entry"
+	CodeMissingField    = "missing_field"
+	CodeInvalidType     = "invalid_type"
+	CodeUnknownField    = "unknown_field"
+	CodeSkippedEntry    = "skipped_entry"
+	CodeInvalidNumber   = "invalid_number"
+	CodeDuplicateNumber = "duplicate_number"
+	CodeDuplicateID     = "duplicate_id"
+	CodeEmptyQuestion   = "empty_question"
+	CodeMissingAnswers  = "missing_answers"
+	CodeEmptyAnswer     = "empty_answer"
+	CodeAnswerCount     = "answer_count"
+	CodeRightAnswer     = "right_answer_out_of_range"
+	CodeMissingImage    = "missing_image"
+	CodeImageReference  = "image_reference_without_image"
+	CodeOrphanedImage   = "orphaned_image"
+	CodeNoQuestions     = "no_questions"
+)
+
+const (
+	minAnswers      = 2 // The smallest number of answers a question can be asked with
+	expectedAnswers = 4 // The number of answers of every question in the official test
+)
+
+// imageReference matches questions that refer to a picture, such as "Was zeigt dieses Bild?"
+// or the answers "Bild 1" to "Bild 4"
+var imageReference = regexp.MustCompile(`\bBild\b`)
+
+// imageExtensions are the file types counted as images when looking for orphaned files
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+// knownFields are the fields of a catalogue entry, see models.Question
+var knownFields = map[string]bool{
+	"ID":           true,
+	"Number":       true,
+	"Question":     true,
+	"Answers":      true,
+	"Right answer": true,
+	"Category":     true,
+	"Topic":        true,
+	"Image":        true,
+}
+
+// Issue is a problem found in the catalogue
+type Issue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Index    int    `json:"index"`            // Position of the entry in the file, or -1 for the file as a whole
+	Number   int    `json:"number,omitempty"` // Question number of the entry, or 0 if unknown
+	Field    string `json:"field,omitempty"`  // Field of the entry the issue is about, if any
+	File     string `json:"file,omitempty"`   // Image file the issue is about, relative to the assets directory
+	Message  string `json:"message"`
+}
+
+// String describes the issue and where it was found
+func (i Issue) String() string {
+	var location string
+	switch {
+	case i.Index < 0 && i.File != "":
+		location = i.File
+	case i.Index < 0:
+		location = "catalogue"
+	case i.Number > 0:
+		location = fmt.Sprintf("entry %d (question #%d)", i.Index, i.Number)
+	default:
+		location = fmt.Sprintf("entry %d", i.Index)
+	}
+	if i.Field != "" {
+		location += ", " + i.Field
+	}
+	return location + ": " + i.Message
+}
+
+// ValidationError reports the errors that make a catalogue unusable
+type ValidationError struct {
+	Path   string
+	Issues []Issue // Only issues with SeverityError
+}
+
+// maxReportedIssues limits the issues listed in the text of a ValidationError
+const maxReportedIssues = 5
+
+// Error lists the first issues and how many more there are
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, maxReportedIssues)
+	for i, issue := range e.Issues {
+		if i == maxReportedIssues {
+			messages = append(messages, fmt.Sprintf("and %d more", len(e.Issues)-maxReportedIssues))
+			break
+		}
+		messages = append(messages, issue.String())
+	}
+	return fmt.Sprintf("invalid catalogue %s: %s", e.Path, strings.Join(messages, "; "))
+}
+
+// HasErrors reports whether any of the issues makes the catalogue unusable
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// File is the content of a catalogue file
+type File struct {
+	Version   string            `json:"Version"` // Empty for files written before catalogues had versions
+	Questions []models.Question `json:"Questions"`
+}
+
+// decodeFile splits a catalogue file into its version and its entries. Besides the
+// versioned form {"Version": ..., "Questions": [...]}, the plain array of questions
+// written before catalogues had versions is accepted.
+func decodeFile(data []byte) (string, []json.RawMessage, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []json.RawMessage
+		err := json.Unmarshal(data, &entries)
+		return "", entries, err
+	}
+
+	var file struct {
+		Version   string
+		Questions []json.RawMessage
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", nil, err
+	}
+	if file.Questions == nil {
+		return "", nil, errors.New("no Questions array")
+	}
+	return file.Version, file.Questions, nil
+}
+
+// Decode reads the questions of a catalogue file without validating them. Entries that
+// can't be decoded are left out, and questions without an ID get their default ID.
+func Decode(data []byte) (*File, error) {
+	version, entries, err := decodeFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{Version: version}
+	for _, raw := range entries {
+		var question models.Question
+		if json.Unmarshal(raw, &question) != nil {
+			continue
+		}
+		if question.ID == "" {
+			question.ID = DefaultID(question.Number)
+		}
+		file.Questions = append(file.Questions, question)
+	}
+	return file, nil
+}
+
+// DefaultID returns the ID of a question that has none in the catalogue file. It is
+// derived from the number, so editing such a question keeps its ID, but renumbering
+// it doesn't; the importer gives every question an explicit ID.
+func DefaultID(number int) string {
+	return fmt.Sprintf("n%d", number)
+}
+
+// Parse validates a catalogue file and returns its version and questions, sorted by
+// number, together with all issues found. Entries with errors are left out; entries
+// numbered -1 are placeholders of the PDF import and are skipped with a warning.
+// Images are looked up relative to dir.
+func Parse(data []byte, dir string) (*File, []Issue) {
+	version, entries, err := decodeFile(data)
+	if err != nil {
+		message := "must be a JSON array of questions or an object with a Version and a Questions array"
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			message = fmt.Sprintf("invalid JSON at byte %d: %v", syntaxErr.Offset, err)
+		}
+		return &File{}, []Issue{{Severity: SeverityError, Code: CodeInvalidJSON, Index: -1, Message: message}}
+	}
+
+	var questions []models.Question
+	var issues []Issue
+	seen := make(map[int]int)       // Question number -> index of the entry
+	seenIDs := make(map[string]int) // Question ID -> index of the entry
+
+	for index, raw := range entries {
+		question, entryIssues := parseEntry(index, raw, dir)
+		issues = append(issues, entryIssues...)
+		if question == nil || HasErrors(entryIssues) {
+			continue
+		}
+
+		if first, ok := seen[question.Number]; ok {
+			issues = append(issues, Issue{Severity: SeverityError, Code: CodeDuplicateNumber, Index: index,
+				Number: question.Number, Field: "Number", Message: fmt.Sprintf("duplicate question number, also used by entry %d", first)})
+			continue
+		}
+		if first, ok := seenIDs[question.ID]; ok {
+			issues = append(issues, Issue{Severity: SeverityError, Code: CodeDuplicateID, Index: index,
+				Number: question.Number, Field: "ID", Message: fmt.Sprintf("duplicate question ID %q, also used by entry %d", question.ID, first)})
+			continue
+		}
+		seen[question.Number] = index
+		seenIDs[question.ID] = index
+		questions = append(questions, *question)
+	}
+
+	if len(questions) == 0 && !HasErrors(issues) {
+		issues = append(issues, Issue{Severity: SeverityError, Code: CodeNoQuestions, Index: -1,
+			Message: "the catalogue contains no questions"})
+	}
+
+	sort.SliceStable(questions, func(i, j int) bool {
+		return questions[i].Number < questions[j].Number
+	})
+
+	return &File{Version: version, Questions: questions}, issues
+}
+
+// parseEntry validates a single catalogue entry. It returns nil for entries that are skipped.
+func parseEntry(index int, raw json.RawMessage, dir string) (*models.Question, []Issue) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return nil, []Issue{{Severity: SeverityError, Code: CodeInvalidEntry, Index: index, Message: "entry is not a JSON object"}}
+	}
+
+	var question models.Question
+	var issues []Issue
+	issue := func(severity, code, field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, Code: code, Index: index, Number: question.Number, Field: field,
+			Message: fmt.Sprintf(format, args...)})
+	}
+
+	// decode reads a field into dst, recording an error if it is required and missing or has the wrong type
+	decode := func(field string, dst interface{}, required bool, kind string) bool {
+		value, ok := fields[field]
+		if !ok {
+			if required {
+				issue(SeverityError, CodeMissingField, field, "missing")
+			}
+			return false
+		}
+		if err := json.Unmarshal(value, dst); err != nil {
+			issue(SeverityError, CodeInvalidType, field, "must be %s", kind)
+			return false
+		}
+		return true
+	}
+
+	if !decode("Number", &question.Number, true, "an integer") {
+		return nil, issues
+	}
+	if question.Number == -1 {
+		issue(SeverityWarning, CodeSkippedEntry, "Number", "entry without a question number is skipped")
+		return nil, issues
+	}
+	if question.Number < 1 {
+		issue(SeverityError, CodeInvalidNumber, "Number", "must be a positive integer, or -1 to skip the entry")
+		return nil, issues
+	}
+
+	if !decode("ID", &question.ID, false, "a string") || strings.TrimSpace(question.ID) == "" {
+		question.ID = DefaultID(question.Number)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !knownFields[name] {
+			issue(SeverityWarning, CodeUnknownField, name, "unknown field is ignored")
+		}
+	}
+
+	if decode("Question", &question.Question, true, "a string") && strings.TrimSpace(question.Question) == "" {
+		issue(SeverityError, CodeEmptyQuestion, "Question", "must not be empty")
+	}
+
+	answersValid := decode("Answers", &question.Answers, true, "an array of strings")
+	if answersValid {
+		switch {
+		case len(question.Answers) < minAnswers:
+			issue(SeverityError, CodeMissingAnswers, "Answers", "must have at least %d answers, has %d", minAnswers, len(question.Answers))
+		case len(question.Answers) != expectedAnswers:
+			issue(SeverityWarning, CodeAnswerCount, "Answers", "has %d answers instead of %d", len(question.Answers), expectedAnswers)
+		}
+		for i, answer := range question.Answers {
+			if strings.TrimSpace(answer) == "" {
+				issue(SeverityWarning, CodeEmptyAnswer, "Answers", "answer %d is empty", i+1)
+			}
+		}
+	}
+
+	if decode("Right answer", &question.RightAnswer, true, "an integer") && answersValid &&
+		(question.RightAnswer < -1 || question.RightAnswer >= len(question.Answers)) {
+		issue(SeverityError, CodeRightAnswer, "Right answer", "must be -1 (unknown) or the index of one of the %d answers, is %d",
+			len(question.Answers), question.RightAnswer)
+	}
+
+	decode("Category", &question.Category, false, "a string")
+	decode("Topic", &question.Topic, false, "a string")
+
+	if decode("Image", &question.Image, false, "a string") && question.Image != "" {
+		if _, err := os.Stat(filepath.Join(dir, question.Image)); err != nil {
+			issue(SeverityWarning, CodeMissingImage, "Image", "image file %s not found", question.Image)
+		}
+	}
+
+	if question.Image == "" && imageReference.MatchString(question.Question+"\n"+strings.Join(question.Answers, "\n")) {
+		issue(SeverityWarning, CodeImageReference, "Image", "the question refers to a picture but has no image")
+	}
+
+	return &question, issues
+}
+
+// Validate checks the question file of an assets directory like Parse does, and also reports
+// image files that no entry refers to. It returns an error only if the files can't be read.
+func Validate(dir string) ([]Issue, error) {
+	data, err := os.ReadFile(filepath.Join(dir, QuestionsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	_, issues := Parse(data, dir)
+
+	referenced := referencedImages(data)
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); !referenced[rel] {
+			issues = append(issues, Issue{Severity: SeverityWarning, Code: CodeOrphanedImage, Index: -1, File: rel,
+				Message: "image file is not used by any entry"})
+		}
+		return nil
+	})
+	return issues, err
+}
+
+// referencedImages returns the image paths of all entries, including the skipped and invalid ones
+func referencedImages(data []byte) map[string]bool {
+	// Entries that can't be decoded have been reported by Parse already
+	_, entries, _ := decodeFile(data)
+
+	referenced := make(map[string]bool)
+	for _, raw := range entries {
+		var entry struct{ Image string }
+		if json.Unmarshal(raw, &entry) == nil && entry.Image != "" {
+			referenced[filepath.ToSlash(filepath.Clean(entry.Image))] = true
+		}
+	}
+	return referenced
+}
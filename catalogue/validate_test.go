@@ -0,0 +1,155 @@
+package catalogue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// validEntry is a catalogue entry without any issue
+const validEntry = `{"Number": 1, "Question": "Wer wählt den Bundestag?", "Answers": ["das Volk", "der Bundesrat", "die Länder", "der Bundespräsident"], "Right answer": 0}`
+
+// findIssue returns the first issue with the given code
+func findIssue(issues []Issue, code string) (Issue, bool) {
+	for _, issue := range issues {
+		if issue.Code == code {
+			return issue, true
+		}
+	}
+	return Issue{}, false
+}
+
+func TestParseIssues(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		code     string
+		severity string
+		field    string
+	}{
+		{"invalid JSON", `[{"Number": 1,`, CodeInvalidJSON, SeverityError, ""},
+		{"neither array nor object with questions", `{"Version": "v1"}`, CodeInvalidJSON, SeverityError, ""},
+		{"entry not an object", `[` + validEntry + `, 42]`, CodeInvalidEntry, SeverityError, ""},
+		{"missing field", `[{"Number": 1, "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeMissingField, SeverityError, "Question"},
+		{"invalid type", `[{"Number": "1", "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeInvalidType, SeverityError, "Number"},
+		{"unknown field", `[{"Number": 1, "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0, "Hint": "x"}]`, CodeUnknownField, SeverityWarning, "Hint"},
+		{"skipped entry", `[` + validEntry + `, {"Number": -1, "Question": "q", "Answers": ["a", "b"], "Right answer": 0}]`, CodeSkippedEntry, SeverityWarning, "Number"},
+		{"invalid number", `[{"Number": 0, "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeInvalidNumber, SeverityError, "Number"},
+		{"duplicate number", `[` + validEntry + `, {"Number": 1, "ID": "other", "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeDuplicateNumber, SeverityError, "Number"},
+		{"duplicate ID", `[{"Number": 1, "ID": "x", "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0}, {"Number": 2, "ID": "x", "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeDuplicateID, SeverityError, "ID"},
+		{"empty question", `[{"Number": 1, "Question": " ", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeEmptyQuestion, SeverityError, "Question"},
+		{"missing answers", `[{"Number": 1, "Question": "q", "Answers": ["a"], "Right answer": 0}]`, CodeMissingAnswers, SeverityError, "Answers"},
+		{"empty answer", `[{"Number": 1, "Question": "q", "Answers": ["a", "", "c", "d"], "Right answer": 0}]`, CodeEmptyAnswer, SeverityWarning, "Answers"},
+		{"answer count", `[{"Number": 1, "Question": "q", "Answers": ["a", "b", "c"], "Right answer": 0}]`, CodeAnswerCount, SeverityWarning, "Answers"},
+		{"right answer out of range", `[{"Number": 1, "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 4}]`, CodeRightAnswer, SeverityError, "Right answer"},
+		{"missing image", `[{"Number": 1, "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": 0, "Image": "missing.png"}]`, CodeMissingImage, SeverityWarning, "Image"},
+		{"image reference", `[{"Number": 1, "Question": "Was zeigt dieses Bild?", "Answers": ["a", "b", "c", "d"], "Right answer": 0}]`, CodeImageReference, SeverityWarning, "Image"},
+		{"no questions", `[]`, CodeNoQuestions, SeverityError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := Parse([]byte(tt.data), t.TempDir())
+			issue, ok := findIssue(issues, tt.code)
+			if !ok {
+				t.Fatalf("Parse found %v, want an issue %s", issues, tt.code)
+			}
+			if issue.Severity != tt.severity || issue.Field != tt.field {
+				t.Errorf("issue = %+v, want severity %s and field %q", issue, tt.severity, tt.field)
+			}
+		})
+	}
+}
+
+func TestParseValidCatalogue(t *testing.T) {
+	data := `{"Version": "v2", "Questions": [` +
+		`{"Number": 2, "ID": "b", "Question": "q", "Answers": ["a", "b", "c", "d"], "Right answer": -1},` +
+		validEntry + `]}`
+
+	file, issues := Parse([]byte(data), t.TempDir())
+	if len(issues) != 0 {
+		t.Fatalf("Parse found %v, want no issues", issues)
+	}
+	if file.Version != "v2" || len(file.Questions) != 2 {
+		t.Fatalf("Parse = %+v, want version v2 with 2 questions", file)
+	}
+	if file.Questions[0].Number != 1 || file.Questions[0].ID != DefaultID(1) || file.Questions[1].ID != "b" {
+		t.Errorf("questions = %+v, want them sorted by number with the default ID for #1", file.Questions)
+	}
+
+	// Files written before catalogues had versions are plain arrays
+	file, issues = Parse([]byte(`[`+validEntry+`]`), t.TempDir())
+	if len(issues) != 0 || file.Version != "" || len(file.Questions) != 1 {
+		t.Errorf("Parse of a plain array = %+v, %v, want 1 question without a version", file, issues)
+	}
+}
+
+func TestParseLeavesOutInvalidEntries(t *testing.T) {
+	data := `[` + validEntry + `, {"Number": 2, "Question": "q", "Answers": ["a"], "Right answer": 0}]`
+
+	file, issues := Parse([]byte(data), t.TempDir())
+	if !HasErrors(issues) {
+		t.Errorf("HasErrors(%v) = false, want true", issues)
+	}
+	if len(file.Questions) != 1 || file.Questions[0].Number != 1 {
+		t.Errorf("questions = %+v, want only #1", file.Questions)
+	}
+}
+
+func TestValidateImages(t *testing.T) {
+	dir := t.TempDir()
+	data := `[{"Number": 1, "Question": "Was zeigt dieses Bild?", "Answers": ["a", "b", "c", "d"], "Right answer": 0, "Image": "images/used.png"},` +
+		`{"Number": -1, "Question": "q", "Answers": ["a", "b"], "Right answer": 0, "Image": "images/skipped.png"}]`
+	files := map[string]string{
+		QuestionsFile:        data,
+		"images/used.png":    "",
+		"images/skipped.png": "",
+		"images/orphan.png":  "",
+		"images/notes.txt":   "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := Validate(dir)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	var orphans []string
+	for _, issue := range issues {
+		switch issue.Code {
+		case CodeOrphanedImage:
+			if issue.Severity != SeverityWarning {
+				t.Errorf("issue = %+v, want a warning", issue)
+			}
+			orphans = append(orphans, issue.File)
+		case CodeMissingImage, CodeImageReference:
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+	if len(orphans) != 1 || orphans[0] != "images/orphan.png" {
+		t.Errorf("orphaned images = %v, want only images/orphan.png", orphans)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	issues := make([]Issue, maxReportedIssues+2)
+	for i := range issues {
+		issues[i] = Issue{Severity: SeverityError, Code: CodeEmptyQuestion, Index: i, Number: i + 1, Field: "Question", Message: "must not be empty"}
+	}
+
+	err := &ValidationError{Path: "questions.json", Issues: issues}
+	want := "invalid catalogue questions.json: entry 0 (question #1), Question: must not be empty; " +
+		"entry 1 (question #2), Question: must not be empty; entry 2 (question #3), Question: must not be empty; " +
+		"entry 3 (question #4), Question: must not be empty; entry 4 (question #5), Question: must not be empty; and 2 more"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
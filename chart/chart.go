@@ -0,0 +1,316 @@
+// Package chart draws simple charts into PNG images. It brings its own bitmap font,
+// so it needs neither font files nor an external service.
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+// Colors of the charts
+var (
+	Background = color.RGBA{255, 255, 255, 255}
+	Ink        = color.RGBA{33, 33, 33, 255} // Titles and labels
+	GridColor  = color.RGBA{224, 224, 224, 255}
+	Blue       = color.RGBA{30, 136, 229, 255}
+	Green      = color.RGBA{67, 160, 71, 255}
+	Orange     = color.RGBA{251, 140, 0, 255}
+	Gray       = color.RGBA{200, 200, 200, 255}
+	Red        = color.RGBA{229, 57, 53, 255}
+)
+
+const (
+	TitleScale = 3 // Pixels per font pixel of chart titles
+	LabelScale = 2 // Pixels per font pixel of labels
+
+	padding      = 16
+	gridLines    = 4  // Horizontal grid lines above the x axis
+	yAxisWidth   = 64 // Room for the labels of the y axis
+	xAxisHeight  = 28 // Room for the labels of the x axis
+	labelSpacing = 12 // Minimum space between two labels of the x axis
+)
+
+// Canvas is an image that charts are drawn on
+type Canvas struct {
+	img *image.RGBA
+}
+
+// NewCanvas returns a blank canvas of the given size in pixels
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.fill(c.img.Bounds(), Background)
+	return c
+}
+
+// PNG encodes the canvas as a PNG image
+func (c *Canvas) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Text draws a line of text with its top left corner at x, y and returns its width
+func (c *Canvas) Text(x, y int, text string, scale int, col color.Color) int {
+	text = asciiFolding.Replace(text)
+	for i, r := range []rune(text) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		left := x + i*(glyphWidth+1)*scale
+		for row, bits := range glyph {
+			for column := 0; column < glyphWidth; column++ {
+				if bits&(1<<(glyphWidth-1-column)) != 0 {
+					c.fill(image.Rect(left+column*scale, y+row*scale, left+(column+1)*scale, y+(row+1)*scale), col)
+				}
+			}
+		}
+	}
+	return TextWidth(text, scale)
+}
+
+// fill paints a rectangle
+func (c *Canvas) fill(r image.Rectangle, col color.Color) {
+	draw.Draw(c.img, r, &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+// line draws a line of the given thickness from x0, y0 to x1, y1
+func (c *Canvas) line(x0, y0, x1, y1, thickness int, col color.Color) {
+	dx, dy := math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0))
+	steps := int(math.Max(dx, dy))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := x0 + int(math.Round(t*float64(x1-x0)))
+		y := y0 + int(math.Round(t*float64(y1-y0)))
+		c.fill(image.Rect(x-thickness/2, y-thickness/2, x-thickness/2+thickness, y-thickness/2+thickness), col)
+	}
+}
+
+// title draws the title of a chart and returns the area below it
+func (c *Canvas) title(area image.Rectangle, title string) image.Rectangle {
+	c.Text(area.Min.X+padding, area.Min.Y+padding, title, TitleScale, Ink)
+	area.Min.Y += padding + TextHeight(TitleScale) + padding
+	return area
+}
+
+// axes draws the grid and the labels of the y axis from 0 to top and the labels of the
+// x axis, and returns the area left for the plot
+func (c *Canvas) axes(area image.Rectangle, labels []string, top float64, format func(float64) string) image.Rectangle {
+	plot := image.Rect(area.Min.X+padding+yAxisWidth, area.Min.Y+TextHeight(LabelScale)/2,
+		area.Max.X-padding, area.Max.Y-padding-xAxisHeight)
+
+	for i := 0; i <= gridLines; i++ {
+		y := plot.Max.Y - i*plot.Dy()/gridLines
+		c.fill(image.Rect(plot.Min.X, y, plot.Max.X, y+1), GridColor)
+		label := format(top * float64(i) / gridLines)
+		c.Text(plot.Min.X-labelSpacing-TextWidth(label, LabelScale), y-TextHeight(LabelScale)/2, label, LabelScale, Ink)
+	}
+
+	if len(labels) == 0 {
+		return plot
+	}
+
+	// Only every step-th label is drawn if they don't fit side by side
+	var widest int
+	for _, label := range labels {
+		widest = max(widest, TextWidth(label, LabelScale))
+	}
+	slot := float64(plot.Dx()) / float64(len(labels))
+	step := max(1, int(math.Ceil(float64(widest+labelSpacing)/slot)))
+	for i := len(labels) - 1; i >= 0; i -= step {
+		width := TextWidth(labels[i], LabelScale)
+		x := min(slotCenter(plot, len(labels), i)-width/2, area.Max.X-width)
+		c.Text(x, plot.Max.Y+padding/2, labels[i], LabelScale, Ink)
+	}
+	return plot
+}
+
+// slotCenter returns the x coordinate of the middle of the i-th of n slots of a plot
+func slotCenter(plot image.Rectangle, n, i int) int {
+	return plot.Min.X + int((float64(i)+0.5)*float64(plot.Dx())/float64(n))
+}
+
+// valueY returns the y coordinate of a value on a plot whose y axis goes from 0 to top
+func valueY(plot image.Rectangle, value, top float64) int {
+	return plot.Max.Y - int(math.Round(value/top*float64(plot.Dy())))
+}
+
+// niceMax rounds the top of a y axis up, so that the grid lines fall on round numbers
+// that are whole numbers for counts
+func niceMax(value float64) float64 {
+	step := value / gridLines
+	if step <= 1 {
+		return gridLines
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, factor := range []float64{1, 2, 2.5, 5, 10} {
+		if nice := factor * magnitude; nice >= step && nice == math.Floor(nice) {
+			return nice * gridLines
+		}
+	}
+	return 10 * magnitude * gridLines
+}
+
+// LineChart is a line through values at evenly spaced labels
+type LineChart struct {
+	Title  string
+	Labels []string
+	Values []float64 // NaN for labels without a value, which leave a gap in the line
+	Max    float64   // Top of the y axis, whose bottom is 0
+	Format func(float64) string
+	Color  color.Color
+
+	Reference      float64 // Value of a dashed reference line, none if 0
+	ReferenceLabel string
+}
+
+// Draw draws the chart into an area of the canvas
+func (l LineChart) Draw(c *Canvas, area image.Rectangle) {
+	plot := c.axes(c.title(area, l.Title), l.Labels, l.Max, l.Format)
+
+	if l.Reference > 0 {
+		y := valueY(plot, l.Reference, l.Max)
+		for x := plot.Min.X; x < plot.Max.X; x += 12 {
+			c.fill(image.Rect(x, y, min(x+6, plot.Max.X), y+2), Red)
+		}
+		if l.ReferenceLabel != "" {
+			width := TextWidth(l.ReferenceLabel, LabelScale)
+			c.Text(plot.Max.X-width, y-TextHeight(LabelScale)-4, l.ReferenceLabel, LabelScale, Red)
+		}
+	}
+
+	const dot = 7
+	previous := -1
+	for i, value := range l.Values {
+		if math.IsNaN(value) {
+			previous = -1
+			continue
+		}
+		x, y := slotCenter(plot, len(l.Values), i), valueY(plot, value, l.Max)
+		if previous >= 0 {
+			c.line(slotCenter(plot, len(l.Values), previous), valueY(plot, l.Values[previous], l.Max), x, y, 3, l.Color)
+		}
+		c.fill(image.Rect(x-dot/2, y-dot/2, x-dot/2+dot, y-dot/2+dot), l.Color)
+		previous = i
+	}
+}
+
+// BarChart is a vertical bar per label
+type BarChart struct {
+	Title  string
+	Labels []string
+	Values []float64
+	Color  color.Color
+}
+
+// Draw draws the chart into an area of the canvas
+func (b BarChart) Draw(c *Canvas, area image.Rectangle) {
+	var largest float64
+	for _, value := range b.Values {
+		largest = math.Max(largest, value)
+	}
+	top := niceMax(largest)
+	plot := c.axes(c.title(area, b.Title), b.Labels, top, func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	})
+
+	width := max(1, int(float64(plot.Dx())/float64(max(1, len(b.Values)))*0.7))
+	for i, value := range b.Values {
+		if value <= 0 {
+			continue
+		}
+		x := slotCenter(plot, len(b.Values), i) - width/2
+		c.fill(image.Rect(x, valueY(plot, value, top), x+width, plot.Max.Y), b.Color)
+	}
+}
+
+// Segment is a part of the bars of a StackedBarChart
+type Segment struct {
+	Label string
+	Color color.Color
+}
+
+// StackedRow is a bar of a StackedBarChart
+type StackedRow struct {
+	Label  string
+	Values []float64 // One per segment
+	Note   string    // Shown after the bar
+}
+
+// StackedBarChart has a horizontal bar per row, split into segments in proportion to
+// their values. Every bar has the full width.
+type StackedBarChart struct {
+	Title    string
+	Segments []Segment
+	Rows     []StackedRow
+}
+
+// Layout of the rows of a StackedBarChart
+const (
+	barHeight  = 24
+	rowSpacing = 14
+	legendBox  = 14
+)
+
+// Height returns the height the chart needs
+func (s StackedBarChart) Height() int {
+	legend := TextHeight(LabelScale) + padding
+	return padding + TextHeight(TitleScale) + padding + legend + len(s.Rows)*(barHeight+rowSpacing) + padding
+}
+
+// Draw draws the chart into an area of the canvas
+func (s StackedBarChart) Draw(c *Canvas, area image.Rectangle) {
+	area = c.title(area, s.Title)
+
+	x := area.Min.X + padding
+	for _, segment := range s.Segments {
+		c.fill(image.Rect(x, area.Min.Y, x+legendBox, area.Min.Y+legendBox), segment.Color)
+		x += legendBox + labelSpacing/2
+		x += c.Text(x, area.Min.Y, segment.Label, LabelScale, Ink) + 2*labelSpacing
+	}
+	area.Min.Y += TextHeight(LabelScale) + padding
+
+	var labelWidth, noteWidth int
+	for _, row := range s.Rows {
+		labelWidth = max(labelWidth, TextWidth(row.Label, LabelScale))
+		noteWidth = max(noteWidth, TextWidth(row.Note, LabelScale))
+	}
+	left := area.Min.X + padding + labelWidth + labelSpacing
+	right := area.Max.X - padding - noteWidth - labelSpacing
+
+	for i, row := range s.Rows {
+		y := area.Min.Y + i*(barHeight+rowSpacing)
+		textY := y + (barHeight-TextHeight(LabelScale))/2
+		c.Text(area.Min.X+padding, textY, row.Label, LabelScale, Ink)
+		c.Text(right+labelSpacing, textY, row.Note, LabelScale, Ink)
+
+		var total float64
+		for _, value := range row.Values {
+			total += value
+		}
+		c.fill(image.Rect(left, y, right, y+barHeight), GridColor)
+		if total <= 0 {
+			continue
+		}
+
+		var sum float64
+		for j, value := range row.Values {
+			from := left + int(math.Round(sum/total*float64(right-left)))
+			sum += value
+			to := left + int(math.Round(sum/total*float64(right-left)))
+			if j < len(s.Segments) {
+				c.fill(image.Rect(from, y, to, y+barHeight), s.Segments[j].Color)
+			}
+		}
+	}
+}
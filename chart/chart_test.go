@@ -0,0 +1,139 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// decode renders the canvas to PNG and decodes it again
+func decode(t *testing.T, c *Canvas) image.Image {
+	t.Helper()
+	data, err := c.PNG()
+	if err != nil {
+		t.Fatalf("PNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding the PNG: %v", err)
+	}
+	return img
+}
+
+// contains returns whether any pixel of the area has the color
+func contains(img image.Image, area image.Rectangle, col color.RGBA) bool {
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == col {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestNiceMax(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{0, 4},
+		{3, 4},
+		{5, 8},
+		{17, 20},
+		{33, 40},
+		{95, 100},
+		{101, 200},
+	}
+
+	for _, tt := range tests {
+		if got := niceMax(tt.value); got != tt.want {
+			t.Errorf("niceMax(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := TextWidth("", LabelScale); got != 0 {
+		t.Errorf("TextWidth of no text = %d, want 0", got)
+	}
+	// Umlauts are drawn as the plain letters
+	if got, want := TextWidth("Thüringen", LabelScale), TextWidth("Thuringen", LabelScale); got != want {
+		t.Errorf("TextWidth(Thüringen) = %d, want %d", got, want)
+	}
+}
+
+func TestDrawWithoutData(t *testing.T) {
+	area := image.Rect(0, 0, 400, 200)
+
+	tests := []struct {
+		name  string
+		chart interface {
+			Draw(*Canvas, image.Rectangle)
+		}
+	}{
+		{"line chart without labels", LineChart{Title: "Empty", Max: 100, Format: func(v float64) string { return "" }, Color: Blue}},
+		{"line chart of gaps only", LineChart{Title: "Gaps", Labels: []string{"a", "b", "c"},
+			Values: []float64{math.NaN(), math.NaN(), math.NaN()}, Max: 100,
+			Format: func(v float64) string { return "" }, Color: Blue}},
+		{"bar chart without labels", BarChart{Title: "Empty", Color: Blue}},
+		{"bar chart of zeros", BarChart{Title: "Zeros", Labels: []string{"a", "b"}, Values: []float64{0, 0}, Color: Blue}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCanvas(area.Dx(), area.Dy())
+			tt.chart.Draw(c, area)
+			img := decode(t, c)
+			if img.Bounds() != area {
+				t.Errorf("image has bounds %v, want %v", img.Bounds(), area)
+			}
+			if contains(img, area, Blue) {
+				t.Errorf("the chart draws data in blue, want none")
+			}
+		})
+	}
+}
+
+func TestStackedBarChart(t *testing.T) {
+	chart := StackedBarChart{
+		Title:    "Mastery",
+		Segments: []Segment{{Label: "Done", Color: Green}, {Label: "Open", Color: Orange}},
+		Rows: []StackedRow{
+			{Label: "Done", Values: []float64{4, 0}, Note: "4/4"},
+			{Label: "Open", Values: []float64{0, 4}, Note: "0/4"},
+			{Label: "Empty", Values: []float64{0, 0}, Note: "0/0"},
+		},
+	}
+	width := 400
+	c := NewCanvas(width, chart.Height())
+	chart.Draw(c, image.Rect(0, 0, width, chart.Height()))
+	img := decode(t, c)
+
+	// Below the legend each bar has the color of its only segment
+	top := padding + TextHeight(TitleScale) + padding + TextHeight(LabelScale) + padding
+	first := image.Rect(0, top, width, top+barHeight)
+	second := first.Add(image.Pt(0, barHeight+rowSpacing))
+	if !contains(img, first, Green) || contains(img, first, Orange) {
+		t.Errorf("the first bar isn't only green")
+	}
+	if !contains(img, second, Orange) || contains(img, second, Green) {
+		t.Errorf("the second bar isn't only orange")
+	}
+	third := second.Add(image.Pt(0, barHeight+rowSpacing))
+	if contains(img, third, Green) || contains(img, third, Orange) || !contains(img, third, GridColor) {
+		t.Errorf("the bar of a row without values isn't empty")
+	}
+
+	// A chart without rows only has its title and legend
+	empty := StackedBarChart{Title: "Empty", Segments: chart.Segments}
+	if got := empty.Height(); got != top+padding {
+		t.Errorf("chart without rows is %d pixels high, want %d", got, top+padding)
+	}
+	c = NewCanvas(width, empty.Height())
+	empty.Draw(c, image.Rect(0, 0, width, empty.Height()))
+	decode(t, c)
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // Config holds all the configuration for the application
@@ -10,6 +13,16 @@ type Config struct {
 	BotToken       string
 	DeepseekAPIKey string
 	DatabasePath   string
+
+	// AssetsDir holds the question catalogue and its images
+	AssetsDir string
+
+	// AdminIDs lists the Telegram user IDs allowed to use operator commands
+	AdminIDs []int64
+
+	// Daily limits on paid AI calls; 0 disables the limit
+	AIUserDailyLimit   int
+	AIGlobalDailyLimit int
 }
 
 // Load loads the configuration from environment variables
@@ -24,15 +37,77 @@ func Load() (*Config, error) {
 		return nil, errors.New("DEEPSEEK_API_KEY environment variable is required")
 	}
 
-	// Set database path with default
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./data/lebentest.db"
+	adminIDs, err := parseIDList(os.Getenv("ADMIN_IDS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_IDS: %w", err)
+	}
+
+	userDailyLimit, err := intFromEnv("AI_USER_DAILY_LIMIT", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	globalDailyLimit, err := intFromEnv("AI_GLOBAL_DAILY_LIMIT", 500)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Config{
-		BotToken:       botToken,
-		DeepseekAPIKey: deepseekAPIKey,
-		DatabasePath:   dbPath,
+		BotToken:           botToken,
+		DeepseekAPIKey:     deepseekAPIKey,
+		DatabasePath:       DatabasePathFromEnv(),
+		AssetsDir:          AssetsDirFromEnv(),
+		AdminIDs:           adminIDs,
+		AIUserDailyLimit:   userDailyLimit,
+		AIGlobalDailyLimit: globalDailyLimit,
 	}, nil
 }
+
+// DatabasePathFromEnv returns the database path set in DB_PATH, or the default one.
+// It is used by commands that don't need the rest of the configuration.
+func DatabasePathFromEnv() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return "./data/lebentest.db"
+}
+
+// AssetsDirFromEnv returns the assets directory set in ASSETS_DIR, or the default one.
+// It is used by commands that don't need the rest of the configuration.
+func AssetsDirFromEnv() string {
+	if dir := os.Getenv("ASSETS_DIR"); dir != "" {
+		return dir
+	}
+	return "assets"
+}
+
+// intFromEnv reads a non-negative integer from an environment variable, falling back to def when unset
+func intFromEnv(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// parseIDList parses a comma-separated list of Telegram IDs
+func parseIDList(value string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a numeric ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// RecordCacheLookup counts an explanation lookup as a cache hit or miss for today
+func (db *DB) RecordCacheLookup(hit bool) error {
+	column := "misses"
+	if hit {
+		column = "hits"
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO cache_stats (day, `+column+`) VALUES (?, 1)
+		ON CONFLICT(day) DO UPDATE SET `+column+` = `+column+` + 1`,
+		time.Now().Format("2006-01-02"),
+	)
+	return err
+}
+
+// GetCacheStatsSince returns the explanation cache hits and misses since the given day (YYYY-MM-DD)
+func (db *DB) GetCacheStatsSince(day string) (hits int, misses int, err error) {
+	err = db.conn.QueryRow(
+		"SELECT COALESCE(SUM(hits), 0), COALESCE(SUM(misses), 0) FROM cache_stats WHERE day >= ?",
+		day,
+	).Scan(&hits, &misses)
+	return hits, misses, err
+}
+
+// CountUsers counts all known users, including those only known from their answers
+func (db *DB) CountUsers() (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM (SELECT user_id FROM users UNION SELECT user_id FROM user_activity)",
+	).Scan(&count)
+	return count, err
+}
+
+// CountActiveUsersSince counts the users who answered a question since the given Unix time
+func (db *DB) CountActiveUsersSince(since int64) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(DISTINCT user_id) FROM user_activity WHERE timestamp >= ?",
+		since,
+	).Scan(&count)
+	return count, err
+}
+
+// CountAnswersSince counts the answers given by all users since the given Unix time
+func (db *DB) CountAnswersSince(since int64) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM user_activity WHERE timestamp >= ?",
+		since,
+	).Scan(&count)
+	return count, err
+}
+
+// GetUserActivityRange returns the Unix times of a user's first and last answer.
+// Both are zero if the user has never answered a question.
+func (db *DB) GetUserActivityRange(userID int64) (first int64, last int64, err error) {
+	var firstNull, lastNull sql.NullInt64
+	err = db.conn.QueryRow(
+		"SELECT MIN(timestamp), MAX(timestamp) FROM user_activity WHERE user_id = ?",
+		userID,
+	).Scan(&firstNull, &lastNull)
+	return firstNull.Int64, lastNull.Int64, err
+}
+
+// GetUserAIUsageSince summarizes a user's AI usage since the given Unix time
+func (db *DB) GetUserAIUsageSince(userID int64, since int64) (models.AIUsageSummary, error) {
+	summary := models.AIUsageSummary{UserID: userID}
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens + completion_tokens), 0), COALESCE(SUM(cost), 0)
+		FROM ai_usage
+		WHERE user_id = ? AND timestamp >= ?`,
+		userID, since,
+	).Scan(&summary.Calls, &summary.Tokens, &summary.Cost)
+	return summary, err
+}
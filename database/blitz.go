@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// GetBlitzBest returns a user's /blitz personal best, or nil if they haven't played yet
+func (db *DB) GetBlitzBest(userID int64) (*models.BlitzBest, error) {
+	best := &models.BlitzBest{UserID: userID}
+	err := db.conn.QueryRow(
+		"SELECT score, questions, rounds, achieved_at FROM blitz_best WHERE user_id = ?",
+		userID,
+	).Scan(&best.Score, &best.Questions, &best.Rounds, &best.AchievedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+// RecordBlitzRound counts a finished /blitz round and updates the personal best if the
+// round beat it. It returns the best before this round (nil if it was the first) and
+// whether the round set a new best.
+func (db *DB) RecordBlitzRound(userID int64, score, questions int) (*models.BlitzBest, bool, error) {
+	previous, err := db.GetBlitzBest(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	newBest := previous == nil || score > previous.Score
+	now := time.Now().Unix()
+
+	if newBest {
+		_, err = db.conn.Exec(`
+			INSERT INTO blitz_best (user_id, score, questions, rounds, achieved_at) VALUES (?, ?, ?, 1, ?)
+			ON CONFLICT(user_id) DO UPDATE SET
+				score = excluded.score,
+				questions = excluded.questions,
+				rounds = rounds + 1,
+				achieved_at = excluded.achieved_at`,
+			userID, score, questions, now,
+		)
+	} else {
+		_, err = db.conn.Exec("UPDATE blitz_best SET rounds = rounds + 1 WHERE user_id = ?", userID)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return previous, newBest, nil
+}
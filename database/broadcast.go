@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// CreateBroadcast queues a broadcast for every active user and returns its ID and number of recipients
+func (db *DB) CreateBroadcast(text string, createdBy int64) (int64, int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+
+	// Make sure everyone who has ever answered a question is a known user
+	if _, err := tx.Exec("INSERT OR IGNORE INTO users (user_id) SELECT DISTINCT user_id FROM user_activity"); err != nil {
+		return 0, 0, err
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO broadcasts (text, status, created_by, created_at) VALUES (?, ?, ?, ?)",
+		text, models.BroadcastRunning, createdBy, now,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO broadcast_deliveries (broadcast_id, user_id, status, updated_at)
+		SELECT ?, user_id, ?, ? FROM users WHERE active = 1`,
+		id, models.DeliveryPending, now,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recipients, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return id, int(recipients), tx.Commit()
+}
+
+// GetBroadcast retrieves a broadcast by ID, or nil if it doesn't exist
+func (db *DB) GetBroadcast(id int64) (*models.Broadcast, error) {
+	var bc models.Broadcast
+	err := db.conn.QueryRow(
+		"SELECT id, text, status, created_by, created_at, finished_at FROM broadcasts WHERE id = ?",
+		id,
+	).Scan(&bc.ID, &bc.Text, &bc.Status, &bc.CreatedBy, &bc.CreatedAt, &bc.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bc, nil
+}
+
+// GetRunningBroadcast returns the oldest broadcast that is being delivered, or nil if there is none
+func (db *DB) GetRunningBroadcast() (*models.Broadcast, error) {
+	var id int64
+	err := db.conn.QueryRow(
+		"SELECT id FROM broadcasts WHERE status = ? ORDER BY id LIMIT 1",
+		models.BroadcastRunning,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db.GetBroadcast(id)
+}
+
+// GetRecentBroadcasts returns the most recent broadcasts, newest first
+func (db *DB) GetRecentBroadcasts(limit int) ([]models.Broadcast, error) {
+	rows, err := db.conn.Query(
+		"SELECT id, text, status, created_by, created_at, finished_at FROM broadcasts ORDER BY id DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Broadcast
+	for rows.Next() {
+		var bc models.Broadcast
+		if err := rows.Scan(&bc.ID, &bc.Text, &bc.Status, &bc.CreatedBy, &bc.CreatedAt, &bc.FinishedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, bc)
+	}
+
+	return result, rows.Err()
+}
+
+// UpdateBroadcastStatus moves a broadcast from one status to another.
+// It returns false if the broadcast doesn't exist or isn't in the expected status.
+func (db *DB) UpdateBroadcastStatus(id int64, from, to string) (bool, error) {
+	finishedAt := int64(0)
+	if to == models.BroadcastDone || to == models.BroadcastCancelled {
+		finishedAt = time.Now().Unix()
+	}
+
+	result, err := db.conn.Exec(
+		"UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ? AND status = ?",
+		to, finishedAt, id, from,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetPendingDeliveries returns up to limit recipients of a broadcast that haven't been sent to yet
+func (db *DB) GetPendingDeliveries(broadcastID int64, limit int) ([]models.BroadcastRecipient, error) {
+	// Users known only from their answers have no stored chat ID; in private chats it equals the user ID
+	rows, err := db.conn.Query(`
+		SELECT d.user_id, COALESCE(NULLIF(u.chat_id, 0), d.user_id)
+		FROM broadcast_deliveries d
+		LEFT JOIN users u ON u.user_id = d.user_id
+		WHERE d.broadcast_id = ? AND d.status = ?
+		ORDER BY d.user_id
+		LIMIT ?`,
+		broadcastID, models.DeliveryPending, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []models.BroadcastRecipient
+	for rows.Next() {
+		var recipient models.BroadcastRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.ChatID); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, rows.Err()
+}
+
+// SetDeliveryStatus records the outcome of delivering a broadcast to a user
+func (db *DB) SetDeliveryStatus(broadcastID, userID int64, status, errorText string) error {
+	_, err := db.conn.Exec(
+		"UPDATE broadcast_deliveries SET status = ?, error = ?, updated_at = ? WHERE broadcast_id = ? AND user_id = ?",
+		status, errorText, time.Now().Unix(), broadcastID, userID,
+	)
+	return err
+}
+
+// GetBroadcastProgress counts the deliveries of a broadcast by status
+func (db *DB) GetBroadcastProgress(broadcastID int64) (models.BroadcastProgress, error) {
+	var progress models.BroadcastProgress
+
+	rows, err := db.conn.Query(
+		"SELECT status, COUNT(*) FROM broadcast_deliveries WHERE broadcast_id = ? GROUP BY status",
+		broadcastID,
+	)
+	if err != nil {
+		return progress, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return progress, err
+		}
+		switch status {
+		case models.DeliveryPending:
+			progress.Pending = count
+		case models.DeliverySent:
+			progress.Sent = count
+		case models.DeliveryFailed:
+			progress.Failed = count
+		case models.DeliveryBlocked:
+			progress.Blocked = count
+		}
+	}
+
+	return progress, rows.Err()
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// questionTables are the tables that refer to questions by number
+var questionTables = []string{
+	"user_activity",
+	"deepseek_cache",
+	"ai_usage",
+	"learn_progress",
+	"presentations",
+	"translations",
+	"reports",
+	"known_questions",
+}
+
+// GetCatalogueVersion returns the version of the catalogue the stored data refers to,
+// or "" if no versioned catalogue was loaded yet
+func (db *DB) GetCatalogueVersion() (string, error) {
+	var version string
+	err := db.conn.QueryRow("SELECT to_version FROM catalogue_migrations ORDER BY id DESC LIMIT 1").Scan(&version)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return version, err
+}
+
+// MigrateCatalogue brings the data stored per question number in line with a catalogue
+// version, given as the question IDs by number. The stored data of a question whose
+// number changed is moved to the new number. Answers and explanations of questions no
+// longer in the catalogue are archived; their translations and known marks are deleted,
+// and their open presentations and reports are closed, so that nothing refers to the
+// question that takes over the number. The first catalogue is recorded as it is.
+// It returns the migration, or nil if neither the version nor any number changed.
+func (db *DB) MigrateCatalogue(version string, ids map[int]string) (*models.CatalogueMigration, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var previousVersion string
+	err = tx.QueryRow("SELECT to_version FROM catalogue_migrations ORDER BY id DESC LIMIT 1").Scan(&previousVersion)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	stored, err := getCatalogueQuestions(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make(map[string]int, len(ids))
+	for number, id := range ids {
+		numbers[id] = number
+	}
+
+	now := time.Now().Unix()
+	migration := &models.CatalogueMigration{FromVersion: previousVersion, ToVersion: version, AppliedAt: now}
+
+	// Questions that are gone go first, so that their numbers are free for the moved ones
+	moves := make(map[int]int) // Old number -> new number
+	for id, oldNumber := range stored {
+		newNumber, ok := numbers[id]
+		if !ok {
+			archived, err := removeCatalogueQuestion(tx, oldNumber, id, previousVersion, now)
+			if err != nil {
+				return nil, err
+			}
+			migration.Removed++
+			migration.Archived += archived
+			continue
+		}
+		if newNumber != oldNumber {
+			moves[oldNumber] = newNumber
+		}
+	}
+	migration.Renumbered = len(moves)
+
+	// Numbers may be swapped, so the rows are moved to the negated new number first
+	if len(moves) > 0 {
+		for _, table := range questionTables {
+			for oldNumber, newNumber := range moves {
+				_, err := tx.Exec("UPDATE "+table+" SET question_number = ? WHERE question_number = ?", -newNumber, oldNumber)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if _, err := tx.Exec("UPDATE " + table + " SET question_number = -question_number WHERE question_number < 0"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM catalogue_questions"); err != nil {
+		return nil, err
+	}
+	for number, id := range ids {
+		if _, err := tx.Exec("INSERT INTO catalogue_questions (question_number, question_id) VALUES (?, ?)", number, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if version == previousVersion && migration.Renumbered == 0 && migration.Removed == 0 {
+		return nil, tx.Commit()
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO catalogue_migrations (from_version, to_version, renumbered, removed, archived, applied_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		migration.FromVersion, migration.ToVersion, migration.Renumbered, migration.Removed, migration.Archived, migration.AppliedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if migration.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+
+	return migration, tx.Commit()
+}
+
+// getCatalogueQuestions returns the numbers the stored data refers to, keyed by question ID
+func getCatalogueQuestions(tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.Query("SELECT question_id, question_number FROM catalogue_questions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var number int
+		if err := rows.Scan(&id, &number); err != nil {
+			return nil, err
+		}
+		stored[id] = number
+	}
+	return stored, rows.Err()
+}
+
+// removeCatalogueQuestion archives the answers and the explanation of a question that is
+// no longer in the catalogue and clears what else refers to its number. Its AI usage is
+// kept for the spend reports and quotas but no longer attributed to a question. It
+// returns the number of archived rows.
+func removeCatalogueQuestion(tx *sql.Tx, number int, id, version string, now int64) (int64, error) {
+	var archived int64
+
+	result, err := tx.Exec(`
+		INSERT INTO user_activity_archive
+			(id, user_id, question_number, question_id, answer_number, correct, timestamp, kind, catalogue_version, archived_at)
+		SELECT id, user_id, question_number, ?, answer_number, correct, timestamp, kind, ?, ?
+		FROM user_activity WHERE question_number = ?`,
+		id, version, now, number,
+	)
+	if err != nil {
+		return 0, err
+	}
+	activities, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	archived += activities
+
+	result, err = tx.Exec(`
+		INSERT INTO deepseek_cache_archive (question_number, question_id, response, right_answer, catalogue_version, archived_at)
+		SELECT question_number, ?, response, right_answer, ?, ?
+		FROM deepseek_cache WHERE question_number = ?`,
+		id, version, now, number,
+	)
+	if err != nil {
+		return 0, err
+	}
+	explanations, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	archived += explanations
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"DELETE FROM user_activity WHERE question_number = ?", []interface{}{number}},
+		{"DELETE FROM deepseek_cache WHERE question_number = ?", []interface{}{number}},
+		{"DELETE FROM translations WHERE question_number = ?", []interface{}{number}},
+		{"DELETE FROM known_questions WHERE question_number = ?", []interface{}{number}},
+		{"UPDATE ai_usage SET question_number = 0 WHERE question_number = ?", []interface{}{number}},
+		{"UPDATE presentations SET answered_at = ? WHERE question_number = ? AND answered_at = 0", []interface{}{now, number}},
+		{"UPDATE reports SET status = ?, resolved_at = ? WHERE question_number = ? AND status = ?",
+			[]interface{}{models.ReportResolved, now, number, models.ReportOpen}},
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			return 0, err
+		}
+	}
+
+	return archived, nil
+}
+
+// getUserArchivedActivity returns a user's answers to questions removed from the catalogue
+func (db *DB) getUserArchivedActivity(userID int64) ([]models.ArchivedActivity, error) {
+	rows, err := db.conn.Query(`
+		SELECT question_number, question_id, answer_number, correct, timestamp, kind, catalogue_version, archived_at
+		FROM user_activity_archive WHERE user_id = ? ORDER BY timestamp`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []models.ArchivedActivity
+	for rows.Next() {
+		activity := models.ArchivedActivity{UserActivity: models.UserActivity{UserID: userID}}
+		if err := rows.Scan(&activity.QuestionNumber, &activity.QuestionID, &activity.AnswerNumber, &activity.Correct,
+			&activity.Timestamp, &activity.Kind, &activity.CatalogueVersion, &activity.ArchivedAt); err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
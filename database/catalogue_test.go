@@ -0,0 +1,204 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// seedQuestion stores an answer and an explanation for a question, both marked with
+// the given text, so that the test can follow them through a migration
+func seedQuestion(t *testing.T, db *DB, number int, mark string) {
+	t.Helper()
+	exec(t, db, "INSERT INTO user_activity (user_id, question_number, answer_number, correct, timestamp) VALUES (1, ?, 0, 1, ?)",
+		number, len(mark))
+	exec(t, db, "INSERT INTO deepseek_cache (question_number, response, right_answer) VALUES (?, ?, 0)", number, mark)
+}
+
+// explanations returns the cached explanations by question number
+func explanations(t *testing.T, db *DB) map[int]string {
+	t.Helper()
+	responses, err := db.GetCachedResponses()
+	if err != nil {
+		t.Fatalf("getting cached responses: %v", err)
+	}
+	return responses
+}
+
+// activityNumbers returns the question numbers of the answers, keyed by their timestamp
+func activityNumbers(t *testing.T, db *DB) map[int64]int {
+	t.Helper()
+	rows, err := db.conn.Query("SELECT timestamp, question_number FROM user_activity WHERE user_id = 1")
+	if err != nil {
+		t.Fatalf("getting activity: %v", err)
+	}
+	defer rows.Close()
+	numbers := make(map[int64]int)
+	for rows.Next() {
+		var timestamp int64
+		var number int
+		if err := rows.Scan(&timestamp, &number); err != nil {
+			t.Fatalf("getting activity: %v", err)
+		}
+		numbers[timestamp] = number
+	}
+	return numbers
+}
+
+func TestMigrateCatalogueFirstRunOnlyRecords(t *testing.T) {
+	db := newTestDB(t)
+	seedQuestion(t, db, 1, "one")
+
+	migration, err := db.MigrateCatalogue("", map[int]string{1: "a", 2: "b"})
+	if err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	if migration != nil {
+		t.Errorf("migration = %+v, want nil for an unversioned first catalogue", migration)
+	}
+	if got := explanations(t, db); got[1] != "one" {
+		t.Errorf("explanations = %v, want them unchanged", got)
+	}
+
+	// A versioned first catalogue is recorded as a migration, but nothing is moved
+	db = newTestDB(t)
+	seedQuestion(t, db, 1, "one")
+	migration, err = db.MigrateCatalogue("v1", map[int]string{1: "a"})
+	if err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	if migration == nil || migration.FromVersion != "" || migration.ToVersion != "v1" ||
+		migration.Renumbered != 0 || migration.Removed != 0 || migration.Archived != 0 {
+		t.Errorf("migration = %+v, want the adoption of v1 only", migration)
+	}
+	if version, _ := db.GetCatalogueVersion(); version != "v1" {
+		t.Errorf("GetCatalogueVersion = %q, want v1", version)
+	}
+}
+
+func TestMigrateCatalogueUnchangedReturnsNil(t *testing.T) {
+	db := newTestDB(t)
+	ids := map[int]string{1: "a", 2: "b"}
+	if _, err := db.MigrateCatalogue("v1", ids); err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	seedQuestion(t, db, 1, "one")
+
+	migration, err := db.MigrateCatalogue("v1", ids)
+	if err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	if migration != nil {
+		t.Errorf("migration = %+v, want nil", migration)
+	}
+	if got := explanations(t, db); len(got) != 1 || got[1] != "one" {
+		t.Errorf("explanations = %v, want them unchanged", got)
+	}
+}
+
+func TestMigrateCatalogueSwapsNumbers(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.MigrateCatalogue("v1", map[int]string{1: "a", 2: "b", 3: "c"}); err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	seedQuestion(t, db, 1, "a")
+	seedQuestion(t, db, 2, "bb")
+	seedQuestion(t, db, 3, "ccc")
+	exec(t, db, "INSERT INTO known_questions (user_id, question_number, marked_at) VALUES (1, 1, 0), (1, 2, 0)")
+
+	migration, err := db.MigrateCatalogue("v2", map[int]string{1: "b", 2: "a", 3: "c"})
+	if err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	if migration == nil || migration.Renumbered != 2 || migration.Removed != 0 || migration.Archived != 0 {
+		t.Fatalf("migration = %+v, want 2 renumbered questions", migration)
+	}
+
+	want := map[int]string{1: "bb", 2: "a", 3: "ccc"}
+	got := explanations(t, db)
+	for number, mark := range want {
+		if got[number] != mark {
+			t.Errorf("explanation of #%d = %q, want %q", number, got[number], mark)
+		}
+	}
+
+	// The answers are told apart by their timestamp, the length of their mark
+	wantNumbers := map[int64]int{1: 2, 2: 1, 3: 3}
+	gotNumbers := activityNumbers(t, db)
+	for timestamp, number := range wantNumbers {
+		if gotNumbers[timestamp] != number {
+			t.Errorf("answer %d is for #%d, want #%d", timestamp, gotNumbers[timestamp], number)
+		}
+	}
+
+	known, err := db.GetKnownQuestions(1)
+	if err != nil {
+		t.Fatalf("GetKnownQuestions: %v", err)
+	}
+	if len(known) != 2 || !known[1] || !known[2] {
+		t.Errorf("known questions = %v, want #1 and #2", known)
+	}
+}
+
+func TestMigrateCatalogueArchivesRemovedQuestion(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.MigrateCatalogue("v1", map[int]string{1: "a", 2: "b"}); err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	seedQuestion(t, db, 1, "a")
+	seedQuestion(t, db, 2, "bb")
+	exec(t, db, "INSERT INTO translations (question_number, language, text, created_at) VALUES (2, 'en', 'old', 0)")
+	exec(t, db, "INSERT INTO reports (user_id, question_number, created_at) VALUES (1, 2, 0)")
+	exec(t, db, `INSERT INTO ai_usage (user_id, question_number, kind, prompt_tokens, completion_tokens, cost, timestamp)
+		VALUES (1, 2, 'help', 10, 20, 0.01, 0)`)
+
+	// b is gone and a new question takes over its number
+	migration, err := db.MigrateCatalogue("v2", map[int]string{1: "a", 2: "new"})
+	if err != nil {
+		t.Fatalf("MigrateCatalogue: %v", err)
+	}
+	if migration == nil || migration.FromVersion != "v1" || migration.ToVersion != "v2" ||
+		migration.Renumbered != 0 || migration.Removed != 1 || migration.Archived != 2 {
+		t.Fatalf("migration = %+v, want 1 removed question with 2 archived rows", migration)
+	}
+
+	if got := explanations(t, db); len(got) != 1 || got[1] != "a" {
+		t.Errorf("explanations = %v, want only that of #1", got)
+	}
+	if got := activityNumbers(t, db); len(got) != 1 || got[1] != 1 {
+		t.Errorf("answers = %v, want only the one to #1", got)
+	}
+
+	archived, err := db.getUserArchivedActivity(1)
+	if err != nil {
+		t.Fatalf("getUserArchivedActivity: %v", err)
+	}
+	if len(archived) != 1 || archived[0].QuestionNumber != 2 || archived[0].QuestionID != "b" ||
+		archived[0].CatalogueVersion != "v1" || archived[0].Timestamp != 2 {
+		t.Errorf("archived answers = %+v, want the answer to b of v1", archived)
+	}
+
+	var id, response, version string
+	err = db.conn.QueryRow("SELECT question_id, response, catalogue_version FROM deepseek_cache_archive WHERE question_number = 2").
+		Scan(&id, &response, &version)
+	if err != nil {
+		t.Fatalf("reading the archived explanation: %v", err)
+	}
+	if id != "b" || response != "bb" || version != "v1" {
+		t.Errorf("archived explanation = %q %q %q, want b bb v1", id, response, version)
+	}
+
+	var translations int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM translations").Scan(&translations); err != nil || translations != 0 {
+		t.Errorf("translations = %d (%v), want the translation of b deleted", translations, err)
+	}
+	report, err := db.GetReport(1)
+	if err != nil || report == nil || report.Status != models.ReportResolved {
+		t.Errorf("report = %+v (%v), want it resolved", report, err)
+	}
+
+	var usageNumber int
+	if err := db.conn.QueryRow("SELECT question_number FROM ai_usage").Scan(&usageNumber); err != nil || usageNumber != 0 {
+		t.Errorf("AI usage refers to #%d (%v), want it kept without a question", usageNumber, err)
+	}
+}
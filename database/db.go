@@ -47,13 +47,19 @@ func createTables(db *sql.DB) error {
 			question_number INTEGER NOT NULL,
 			answer_number INTEGER NOT NULL,
 			correct BOOLEAN NOT NULL,
-			timestamp INTEGER NOT NULL
+			timestamp INTEGER NOT NULL,
+			kind TEXT NOT NULL DEFAULT 'quiz'
 		)
 	`)
 	if err != nil {
 		return err
 	}
 
+	// Activity recorded before flashcards existed was always a quiz answer
+	if err = addColumnIfMissing(db, "user_activity", "kind", "TEXT NOT NULL DEFAULT 'quiz'"); err != nil {
+		return err
+	}
+
 	// Create deepseek cache table
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS deepseek_cache (
@@ -62,6 +68,302 @@ func createTables(db *sql.DB) error {
 			right_answer INTEGER NOT NULL
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	// Create AI usage table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_usage (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			question_number INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			prompt_tokens INTEGER NOT NULL,
+			completion_tokens INTEGER NOT NULL,
+			cost REAL NOT NULL,
+			timestamp INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_ai_usage_timestamp ON ai_usage (timestamp)")
+	if err != nil {
+		return err
+	}
+
+	// Create explanation cache statistics table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS cache_stats (
+			day TEXT PRIMARY KEY,
+			hits INTEGER NOT NULL DEFAULT 0,
+			misses INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create users table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS users (
+			user_id INTEGER PRIMARY KEY,
+			chat_id INTEGER NOT NULL DEFAULT 0,
+			username TEXT NOT NULL DEFAULT '',
+			first_name TEXT NOT NULL DEFAULT '',
+			last_name TEXT NOT NULL DEFAULT '',
+			language_code TEXT NOT NULL DEFAULT '',
+			first_seen INTEGER NOT NULL DEFAULT 0,
+			last_seen INTEGER NOT NULL DEFAULT 0,
+			active BOOLEAN NOT NULL DEFAULT 1,
+			inactive_since INTEGER NOT NULL DEFAULT 0,
+			language TEXT NOT NULL DEFAULT '',
+			bundesland TEXT NOT NULL DEFAULT '',
+			reminder_time TEXT NOT NULL DEFAULT '',
+			presentation TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add the profile columns to users tables created before they existed
+	for _, column := range []struct{ name, definition string }{
+		{"chat_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"username", "TEXT NOT NULL DEFAULT ''"},
+		{"first_name", "TEXT NOT NULL DEFAULT ''"},
+		{"last_name", "TEXT NOT NULL DEFAULT ''"},
+		{"language_code", "TEXT NOT NULL DEFAULT ''"},
+		{"first_seen", "INTEGER NOT NULL DEFAULT 0"},
+		{"last_seen", "INTEGER NOT NULL DEFAULT 0"},
+		{"language", "TEXT NOT NULL DEFAULT ''"},
+		{"bundesland", "TEXT NOT NULL DEFAULT ''"},
+		{"reminder_time", "TEXT NOT NULL DEFAULT ''"},
+		{"presentation", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if err = addColumnIfMissing(db, "users", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	// Create broadcast tables
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcasts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created_by INTEGER NOT NULL,
+			created_at INTEGER NOT NULL,
+			finished_at INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+			broadcast_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (broadcast_id, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group quiz leaderboard table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_scores (
+			chat_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			correct INTEGER NOT NULL DEFAULT 0,
+			answered INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (chat_id, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create sequential learning mode progress table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS learn_progress (
+			user_id INTEGER PRIMARY KEY,
+			question_number INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create /blitz personal best table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS blitz_best (
+			user_id INTEGER PRIMARY KEY,
+			score INTEGER NOT NULL,
+			questions INTEGER NOT NULL,
+			rounds INTEGER NOT NULL DEFAULT 0,
+			achieved_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create question presentations table, so every presented question is answered only once
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS presentations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			question_number INTEGER NOT NULL,
+			created_at INTEGER NOT NULL,
+			answered_at INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create AI translation cache table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS translations (
+			question_number INTEGER NOT NULL,
+			language TEXT NOT NULL,
+			text TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (question_number, language)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create question problem reports table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			question_number INTEGER NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			comment TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			resolved_by INTEGER NOT NULL DEFAULT 0,
+			resolved_at INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Reports made before they had a reason and a moderation status
+	for _, column := range []struct{ name, definition string }{
+		{"reason", "TEXT NOT NULL DEFAULT ''"},
+		{"comment", "TEXT NOT NULL DEFAULT ''"},
+		{"status", "TEXT NOT NULL DEFAULT 'open'"},
+		{"resolved_by", "INTEGER NOT NULL DEFAULT 0"},
+		{"resolved_at", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err = addColumnIfMissing(db, "reports", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	// Right answers set by an admin, which a new AI analysis must not replace
+	if err = addColumnIfMissing(db, "deepseek_cache", "answer_overridden", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Create table of questions users marked as known
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS known_questions (
+			user_id INTEGER NOT NULL,
+			question_number INTEGER NOT NULL,
+			marked_at INTEGER NOT NULL,
+			PRIMARY KEY (user_id, question_number)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the table of the question IDs the stored question numbers refer to
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS catalogue_questions (
+			question_number INTEGER PRIMARY KEY,
+			question_id TEXT NOT NULL UNIQUE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the log of catalogue versions the stored data was migrated to
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS catalogue_migrations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			from_version TEXT NOT NULL,
+			to_version TEXT NOT NULL,
+			renumbered INTEGER NOT NULL,
+			removed INTEGER NOT NULL,
+			archived INTEGER NOT NULL,
+			applied_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create the archives of answers and explanations of questions removed from the catalogue
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_activity_archive (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			question_number INTEGER NOT NULL,
+			question_id TEXT NOT NULL,
+			answer_number INTEGER NOT NULL,
+			correct BOOLEAN NOT NULL,
+			timestamp INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			catalogue_version TEXT NOT NULL,
+			archived_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS deepseek_cache_archive (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_number INTEGER NOT NULL,
+			question_id TEXT NOT NULL,
+			response TEXT NOT NULL,
+			right_answer INTEGER NOT NULL,
+			catalogue_version TEXT NOT NULL,
+			archived_at INTEGER NOT NULL
+		)
+	`)
+	return err
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already there
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
 	return err
 }
 
@@ -74,30 +376,152 @@ func (db *DB) SaveUserActivity(userID int64, questionNumber, answerNumber int, c
 	return err
 }
 
-// GetUserStats retrieves statistics about the user's answers
+// SaveFlashcardReview records a learner's self-assessment of a flashcard. Ratings of
+// Good or Easy count as knowing the answer.
+func (db *DB) SaveFlashcardReview(userID int64, questionNumber, rating int) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO user_activity (user_id, question_number, answer_number, correct, timestamp, kind) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, questionNumber, rating, rating >= models.FlashcardGood, time.Now().Unix(), models.ActivityFlashcard,
+	)
+	return err
+}
+
+// CountFlashcardReviews returns how many flashcards the user has rated
+func (db *DB) CountFlashcardReviews(userID int64) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM user_activity WHERE user_id = ? AND kind = ?",
+		userID, models.ActivityFlashcard,
+	).Scan(&count)
+	return count, err
+}
+
+// GetUserActivity returns all answers and flashcard ratings of a user, oldest first
+func (db *DB) GetUserActivity(userID int64) ([]models.UserActivity, error) {
+	rows, err := db.conn.Query(
+		"SELECT question_number, answer_number, correct, timestamp, kind FROM user_activity WHERE user_id = ? ORDER BY timestamp, id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []models.UserActivity
+	for rows.Next() {
+		activity := models.UserActivity{UserID: userID}
+		if err := rows.Scan(&activity.QuestionNumber, &activity.AnswerNumber, &activity.Correct, &activity.Timestamp, &activity.Kind); err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+// GetUserStats retrieves statistics about the user's quiz answers
 func (db *DB) GetUserStats(userID int64) (correct int, incorrect int, err error) {
 	err = db.conn.QueryRow(
-		"SELECT COUNT(*) FROM user_activity WHERE user_id = ? AND correct = 1",
-		userID,
+		"SELECT COUNT(*) FROM user_activity WHERE user_id = ? AND kind = ? AND correct = 1",
+		userID, models.ActivityQuiz,
 	).Scan(&correct)
 	if err != nil {
 		return 0, 0, err
 	}
 
 	err = db.conn.QueryRow(
-		"SELECT COUNT(*) FROM user_activity WHERE user_id = ? AND correct = 0",
-		userID,
+		"SELECT COUNT(*) FROM user_activity WHERE user_id = ? AND kind = ? AND correct = 0",
+		userID, models.ActivityQuiz,
 	).Scan(&incorrect)
 	return correct, incorrect, err
 }
 
-// CacheDeepseekResponse stores a response from Deepseek API
+// CacheDeepseekResponse stores a response from Deepseek API. If it makes the right
+// answer known or changes it, the answers already given to the question are regraded.
+// A right answer set by an admin with OverrideRightAnswer is kept.
 func (db *DB) CacheDeepseekResponse(questionNumber int, response string, rightAnswer int) error {
-	_, err := db.conn.Exec(
-		"INSERT OR REPLACE INTO deepseek_cache (question_number, response, right_answer) VALUES (?, ?, ?)",
-		questionNumber, response, rightAnswer,
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	previous := -1
+	var overridden bool
+	err = tx.QueryRow("SELECT right_answer, answer_overridden FROM deepseek_cache WHERE question_number = ?", questionNumber).
+		Scan(&previous, &overridden)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if overridden {
+		rightAnswer = previous
+	}
+
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO deepseek_cache (question_number, response, right_answer, answer_overridden) VALUES (?, ?, ?, ?)",
+		questionNumber, response, rightAnswer, overridden,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if rightAnswer != -1 && rightAnswer != previous {
+		if _, err := regradeActivities(tx, questionNumber, rightAnswer); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// OverrideRightAnswer sets the right answer of a question on an admin's behalf, keeping
+// its explanation, and regrades the answers already given to it. Later AI responses
+// cached for the question don't change it again.
+func (db *DB) OverrideRightAnswer(questionNumber, rightAnswer int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO deepseek_cache (question_number, response, right_answer, answer_overridden) VALUES (?, '', ?, 1)
+		ON CONFLICT (question_number) DO UPDATE SET right_answer = excluded.right_answer, answer_overridden = 1`,
+		questionNumber, rightAnswer,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := regradeActivities(tx, questionNumber, rightAnswer); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RegradeActivities re-evaluates whether the quiz answers to a question were correct,
+// after its right answer became known or changed. It returns the number of answers
+// whose grade changed.
+func (db *DB) RegradeActivities(questionNumber, rightAnswer int) (int64, error) {
+	return regradeActivities(db.conn, questionNumber, rightAnswer)
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// regradeActivities implements RegradeActivities on a connection or transaction
+func regradeActivities(conn execer, questionNumber, rightAnswer int) (int64, error) {
+	result, err := conn.Exec(`
+		UPDATE user_activity SET correct = (answer_number = ?)
+		WHERE question_number = ? AND kind = ? AND correct != (answer_number = ?)`,
+		rightAnswer, questionNumber, models.ActivityQuiz, rightAnswer,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 // GetCachedDeepseekResponse retrieves a cached response
@@ -116,16 +540,57 @@ func (db *DB) GetCachedDeepseekResponse(questionNumber int) (string, int, error)
 	return response, rightAnswer, err
 }
 
+// GetKnownRightAnswers returns the right answers determined so far, keyed by question number
+func (db *DB) GetKnownRightAnswers() (map[int]int, error) {
+	rows, err := db.conn.Query("SELECT question_number, right_answer FROM deepseek_cache WHERE right_answer != -1")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := make(map[int]int)
+	for rows.Next() {
+		var questionNumber, rightAnswer int
+		if err := rows.Scan(&questionNumber, &rightAnswer); err != nil {
+			return nil, err
+		}
+		answers[questionNumber] = rightAnswer
+	}
+
+	return answers, rows.Err()
+}
+
+// GetCachedResponses returns all cached AI explanations, keyed by question number
+func (db *DB) GetCachedResponses() (map[int]string, error) {
+	rows, err := db.conn.Query("SELECT question_number, response FROM deepseek_cache")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := make(map[int]string)
+	for rows.Next() {
+		var questionNumber int
+		var response string
+		if err := rows.Scan(&questionNumber, &response); err != nil {
+			return nil, err
+		}
+		responses[questionNumber] = response
+	}
+
+	return responses, rows.Err()
+}
+
 // GetMostFrequentIncorrectQuestions gets the questions most frequently answered incorrectly
 func (db *DB) GetMostFrequentIncorrectQuestions(userID int64, limit int) ([]models.UserActivity, error) {
 	rows, err := db.conn.Query(`
 		SELECT question_number, COUNT(*) as count 
 		FROM user_activity 
-		WHERE user_id = ? AND correct = 0 
+		WHERE user_id = ? AND kind = ? AND correct = 0 
 		GROUP BY question_number 
 		ORDER BY count DESC 
 		LIMIT ?
-	`, userID, limit)
+	`, userID, models.ActivityQuiz, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB opens a database in a temporary file
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// exec runs a statement that prepares the test data
+func exec(t *testing.T, db *DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.conn.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// answerCorrect returns whether the only recorded answer to a question is graded right
+func answerCorrect(t *testing.T, db *DB, questionNumber int) bool {
+	t.Helper()
+	var correct bool
+	if err := db.conn.QueryRow("SELECT correct FROM user_activity WHERE question_number = ?", questionNumber).Scan(&correct); err != nil {
+		t.Fatalf("reading the answer: %v", err)
+	}
+	return correct
+}
+
+func TestOverrideRightAnswerSurvivesNewResponses(t *testing.T) {
+	db := newTestDB(t)
+	exec(t, db, "INSERT INTO user_activity (user_id, question_number, answer_number, correct, timestamp) VALUES (1, 5, 2, 0, 0)")
+
+	if err := db.CacheDeepseekResponse(5, "first", 0); err != nil {
+		t.Fatalf("CacheDeepseekResponse: %v", err)
+	}
+	if answerCorrect(t, db, 5) {
+		t.Errorf("answer 2 is graded right, want wrong with right answer 0")
+	}
+
+	if err := db.OverrideRightAnswer(5, 2); err != nil {
+		t.Fatalf("OverrideRightAnswer: %v", err)
+	}
+	if response, rightAnswer, _ := db.GetCachedDeepseekResponse(5); response != "first" || rightAnswer != 2 {
+		t.Errorf("cache = %q, %d, want the explanation kept and right answer 2", response, rightAnswer)
+	}
+	if !answerCorrect(t, db, 5) {
+		t.Errorf("answer 2 is graded wrong after the override")
+	}
+
+	// A new analysis, e.g. by /admin regen, replaces the explanation but not the answer
+	if err := db.CacheDeepseekResponse(5, "second", 0); err != nil {
+		t.Fatalf("CacheDeepseekResponse: %v", err)
+	}
+	if response, rightAnswer, _ := db.GetCachedDeepseekResponse(5); response != "second" || rightAnswer != 2 {
+		t.Errorf("cache = %q, %d, want the new explanation with right answer 2", response, rightAnswer)
+	}
+	if !answerCorrect(t, db, 5) {
+		t.Errorf("answer 2 was regraded back to wrong")
+	}
+}
+
+func TestOverrideRightAnswerWithoutExplanation(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.OverrideRightAnswer(7, 1); err != nil {
+		t.Fatalf("OverrideRightAnswer: %v", err)
+	}
+	if response, rightAnswer, _ := db.GetCachedDeepseekResponse(7); response != "" || rightAnswer != 1 {
+		t.Errorf("cache = %q, %d, want no explanation and right answer 1", response, rightAnswer)
+	}
+
+	if err := db.CacheDeepseekResponse(7, "explained", -1); err != nil {
+		t.Fatalf("CacheDeepseekResponse: %v", err)
+	}
+	if response, rightAnswer, _ := db.GetCachedDeepseekResponse(7); response != "explained" || rightAnswer != 1 {
+		t.Errorf("cache = %q, %d, want the explanation with right answer 1", response, rightAnswer)
+	}
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// GetTranslation returns the cached translation of a question into a language, or "" if there is none
+func (db *DB) GetTranslation(questionNumber int, language string) (string, error) {
+	var text string
+	err := db.conn.QueryRow(
+		"SELECT text FROM translations WHERE question_number = ? AND language = ?",
+		questionNumber, language,
+	).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return text, err
+}
+
+// SaveTranslation caches the translation of a question into a language
+func (db *DB) SaveTranslation(questionNumber int, language, text string) error {
+	_, err := db.conn.Exec(
+		"INSERT OR REPLACE INTO translations (question_number, language, text, created_at) VALUES (?, ?, ?, ?)",
+		questionNumber, language, text, time.Now().Unix(),
+	)
+	return err
+}
+
+// CreateReport records a problem with a question reported by a user and returns its ID
+func (db *DB) CreateReport(userID int64, questionNumber int, reason string) (int64, error) {
+	result, err := db.conn.Exec(
+		"INSERT INTO reports (user_id, question_number, reason, created_at) VALUES (?, ?, ?, ?)",
+		userID, questionNumber, reason, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// SetReportComment adds the user's description of the problem to their report
+func (db *DB) SetReportComment(reportID, userID int64, comment string) error {
+	_, err := db.conn.Exec(
+		"UPDATE reports SET comment = ? WHERE id = ? AND user_id = ?",
+		comment, reportID, userID,
+	)
+	return err
+}
+
+// GetReport returns a report, or nil if it doesn't exist
+func (db *DB) GetReport(reportID int64) (*models.Report, error) {
+	var report models.Report
+	err := db.conn.QueryRow(
+		"SELECT "+reportColumns+" FROM reports WHERE id = ?",
+		reportID,
+	).Scan(reportFields(&report)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetOpenReports returns up to limit reports that haven't been resolved, oldest first
+func (db *DB) GetOpenReports(limit int) ([]models.Report, error) {
+	return db.queryReports(
+		"SELECT "+reportColumns+" FROM reports WHERE status = ? ORDER BY id LIMIT ?",
+		models.ReportOpen, limit,
+	)
+}
+
+// CountOpenReports returns the number of reports that haven't been resolved
+func (db *DB) CountOpenReports() (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM reports WHERE status = ?", models.ReportOpen).Scan(&count)
+	return count, err
+}
+
+// ResolveReport marks an open report as resolved by an admin.
+// It reports whether the report was open.
+func (db *DB) ResolveReport(reportID, adminID int64) (bool, error) {
+	result, err := db.conn.Exec(
+		"UPDATE reports SET status = ?, resolved_by = ?, resolved_at = ? WHERE id = ? AND status = ?",
+		models.ReportResolved, adminID, time.Now().Unix(), reportID, models.ReportOpen,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// reportColumns are the columns scanned by reportFields
+const reportColumns = "id, user_id, question_number, reason, comment, status, resolved_by, resolved_at, created_at"
+
+// reportFields returns the scan destinations of reportColumns
+func reportFields(report *models.Report) []interface{} {
+	return []interface{}{
+		&report.ID, &report.UserID, &report.QuestionNumber, &report.Reason, &report.Comment,
+		&report.Status, &report.ResolvedBy, &report.ResolvedAt, &report.CreatedAt,
+	}
+}
+
+// queryReports runs a query selecting reportColumns
+func (db *DB) queryReports(query string, args ...interface{}) ([]models.Report, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []models.Report
+	for rows.Next() {
+		var report models.Report
+		if err := rows.Scan(reportFields(&report)...); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+// MarkQuestionKnown excludes a question from the user's practice selection
+func (db *DB) MarkQuestionKnown(userID int64, questionNumber int) error {
+	_, err := db.conn.Exec(
+		"INSERT OR IGNORE INTO known_questions (user_id, question_number, marked_at) VALUES (?, ?, ?)",
+		userID, questionNumber, time.Now().Unix(),
+	)
+	return err
+}
+
+// GetKnownQuestions returns the questions the user marked as known, keyed by question number
+func (db *DB) GetKnownQuestions(userID int64) (map[int]bool, error) {
+	rows, err := db.conn.Query("SELECT question_number FROM known_questions WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]bool)
+	for rows.Next() {
+		var questionNumber int
+		if err := rows.Scan(&questionNumber); err != nil {
+			return nil, err
+		}
+		known[questionNumber] = true
+	}
+
+	return known, rows.Err()
+}
+
+// ResetKnownQuestions brings all questions the user marked as known back into practice
+func (db *DB) ResetKnownQuestions(userID int64) (int64, error) {
+	result, err := db.conn.Exec("DELETE FROM known_questions WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// getUserReports returns the reports a user made, oldest first
+func (db *DB) getUserReports(userID int64) ([]models.Report, error) {
+	return db.queryReports("SELECT "+reportColumns+" FROM reports WHERE user_id = ? ORDER BY id", userID)
+}
+
+// getUserKnownQuestions returns the questions a user marked as known, in the order they were marked
+func (db *DB) getUserKnownQuestions(userID int64) ([]models.KnownQuestion, error) {
+	rows, err := db.conn.Query(
+		"SELECT question_number, marked_at FROM known_questions WHERE user_id = ? ORDER BY marked_at, question_number",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var known []models.KnownQuestion
+	for rows.Next() {
+		var question models.KnownQuestion
+		if err := rows.Scan(&question.QuestionNumber, &question.MarkedAt); err != nil {
+			return nil, err
+		}
+		known = append(known, question)
+	}
+
+	return known, rows.Err()
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// AddGroupScore adds the result of one group quiz question to a member's leaderboard entry
+func (db *DB) AddGroupScore(chatID, userID int64, correct bool) error {
+	correctCount := 0
+	if correct {
+		correctCount = 1
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO group_scores (chat_id, user_id, correct, answered) VALUES (?, ?, ?, 1)
+		ON CONFLICT(chat_id, user_id) DO UPDATE SET
+			correct = correct + excluded.correct,
+			answered = answered + 1`,
+		chatID, userID, correctCount,
+	)
+	return err
+}
+
+// GetGroupLeaderboard returns the best members of a group, by correct answers
+func (db *DB) GetGroupLeaderboard(chatID int64, limit int) ([]models.GroupScore, error) {
+	rows, err := db.conn.Query(`
+		SELECT s.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), s.correct, s.answered
+		FROM group_scores s
+		LEFT JOIN users u ON u.user_id = s.user_id
+		WHERE s.chat_id = ?
+		ORDER BY s.correct DESC, s.answered ASC
+		LIMIT ?`,
+		chatID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.GroupScore
+	for rows.Next() {
+		score := models.GroupScore{ChatID: chatID}
+		if err := rows.Scan(&score.UserID, &score.Username, &score.FirstName, &score.Correct, &score.Answered); err != nil {
+			return nil, err
+		}
+		result = append(result, score)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GetLearnPosition returns the question number a user reached in the sequential
+// learning mode, or 0 if they haven't used it yet
+func (db *DB) GetLearnPosition(userID int64) (int, error) {
+	var questionNumber int
+	err := db.conn.QueryRow("SELECT question_number FROM learn_progress WHERE user_id = ?", userID).Scan(&questionNumber)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return questionNumber, err
+}
+
+// SetLearnPosition remembers the question a user is at in the sequential learning mode
+func (db *DB) SetLearnPosition(userID int64, questionNumber int) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO learn_progress (user_id, question_number, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			question_number = excluded.question_number,
+			updated_at = excluded.updated_at`,
+		userID, questionNumber, time.Now().Unix(),
+	)
+	return err
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// presentationTTL is how long a presented question can be answered
+const presentationTTL = 24 * time.Hour
+
+// ErrNotPresentedToUser is returned when a user answers a question that was shown to someone else
+var ErrNotPresentedToUser = errors.New("the question was presented to another user")
+
+// CreatePresentation records that a question was shown to a user and returns its ID.
+// The questions shown to the user in the chat before can no longer be answered.
+func (db *DB) CreatePresentation(userID, chatID int64, questionNumber int) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	_, err = tx.Exec(
+		"UPDATE presentations SET answered_at = ? WHERE user_id = ? AND chat_id = ? AND answered_at = 0",
+		now, userID, chatID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO presentations (user_id, chat_id, question_number, created_at) VALUES (?, ?, ?, ?)",
+		userID, chatID, questionNumber, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// ClaimPresentation marks a presentation as answered by the user it was shown to. It returns
+// the presentation, or nil if it doesn't exist, has expired or was already answered, and
+// ErrNotPresentedToUser if it is still open but was shown to another user.
+func (db *DB) ClaimPresentation(id, userID int64) (*models.Presentation, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(
+		"UPDATE presentations SET answered_at = ? WHERE id = ? AND user_id = ? AND answered_at = 0 AND created_at >= ?",
+		now.Unix(), id, userID, now.Add(-presentationTTL).Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if claimed == 0 {
+		var owner int64
+		err := db.conn.QueryRow(
+			"SELECT user_id FROM presentations WHERE id = ? AND answered_at = 0 AND created_at >= ?",
+			id, now.Add(-presentationTTL).Unix(),
+		).Scan(&owner)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if owner != userID {
+			return nil, ErrNotPresentedToUser
+		}
+		return nil, nil
+	}
+
+	presentation := &models.Presentation{ID: id, UserID: userID}
+	err = db.conn.QueryRow(
+		"SELECT chat_id, question_number, created_at, answered_at FROM presentations WHERE id = ?",
+		id,
+	).Scan(&presentation.ChatID, &presentation.QuestionNumber, &presentation.CreatedAt, &presentation.AnsweredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return presentation, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// SaveAIUsage records the tokens and cost of a paid AI call
+func (db *DB) SaveAIUsage(usage models.AIUsage) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ai_usage (user_id, question_number, kind, prompt_tokens, completion_tokens, cost, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		usage.UserID, usage.QuestionNumber, usage.Kind, usage.PromptTokens, usage.CompletionTokens,
+		usage.Cost, usage.Timestamp,
+	)
+	return err
+}
+
+// CountUserAIUsageSince counts the AI calls made for a user since the given Unix time
+func (db *DB) CountUserAIUsageSince(userID int64, since int64) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM ai_usage WHERE user_id = ? AND timestamp >= ?",
+		userID, since,
+	).Scan(&count)
+	return count, err
+}
+
+// CountAIUsageSince counts the AI calls made for all users since the given Unix time
+func (db *DB) CountAIUsageSince(since int64) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM ai_usage WHERE timestamp >= ?",
+		since,
+	).Scan(&count)
+	return count, err
+}
+
+// GetAIUsageByDay summarizes AI usage per day since the given Unix time, newest day first
+func (db *DB) GetAIUsageByDay(since int64) ([]models.AIUsageSummary, error) {
+	rows, err := db.conn.Query(`
+		SELECT date(timestamp, 'unixepoch', 'localtime') AS day, COUNT(*),
+			SUM(prompt_tokens + completion_tokens), SUM(cost)
+		FROM ai_usage
+		WHERE timestamp >= ?
+		GROUP BY day
+		ORDER BY day DESC`,
+		since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.AIUsageSummary
+	for rows.Next() {
+		var summary models.AIUsageSummary
+		if err := rows.Scan(&summary.Day, &summary.Calls, &summary.Tokens, &summary.Cost); err != nil {
+			return nil, err
+		}
+		result = append(result, summary)
+	}
+
+	return result, rows.Err()
+}
+
+// GetAIUsageByUser summarizes AI usage per user since the given Unix time, most expensive first
+func (db *DB) GetAIUsageByUser(since int64, limit int) ([]models.AIUsageSummary, error) {
+	rows, err := db.conn.Query(`
+		SELECT user_id, COUNT(*), SUM(prompt_tokens + completion_tokens), SUM(cost) AS total_cost
+		FROM ai_usage
+		WHERE timestamp >= ?
+		GROUP BY user_id
+		ORDER BY total_cost DESC
+		LIMIT ?`,
+		since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.AIUsageSummary
+	for rows.Next() {
+		var summary models.AIUsageSummary
+		if err := rows.Scan(&summary.UserID, &summary.Calls, &summary.Tokens, &summary.Cost); err != nil {
+			return nil, err
+		}
+		result = append(result, summary)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// ExportUserData collects everything stored about a user
+func (db *DB) ExportUserData(userID int64) (*models.UserDataExport, error) {
+	export := &models.UserDataExport{ExportedAt: time.Now().Unix()}
+
+	profile, err := db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Profile = profile
+
+	export.Activity, err = db.GetUserActivity(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export.ArchivedActivity, err = db.getUserArchivedActivity(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usageRows, err := db.conn.Query(`
+		SELECT question_number, kind, prompt_tokens, completion_tokens, cost, timestamp
+		FROM ai_usage WHERE user_id = ? ORDER BY timestamp`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer usageRows.Close()
+
+	for usageRows.Next() {
+		usage := models.AIUsage{UserID: userID}
+		if err := usageRows.Scan(&usage.QuestionNumber, &usage.Kind, &usage.PromptTokens, &usage.CompletionTokens,
+			&usage.Cost, &usage.Timestamp); err != nil {
+			return nil, err
+		}
+		export.AIUsage = append(export.AIUsage, usage)
+	}
+	if err := usageRows.Err(); err != nil {
+		return nil, err
+	}
+
+	deliveryRows, err := db.conn.Query(
+		"SELECT broadcast_id, status, error, updated_at FROM broadcast_deliveries WHERE user_id = ? ORDER BY broadcast_id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer deliveryRows.Close()
+
+	for deliveryRows.Next() {
+		delivery := models.BroadcastDelivery{UserID: userID}
+		if err := deliveryRows.Scan(&delivery.BroadcastID, &delivery.Status, &delivery.Error, &delivery.UpdatedAt); err != nil {
+			return nil, err
+		}
+		export.BroadcastDeliveries = append(export.BroadcastDeliveries, delivery)
+	}
+
+	if err := deliveryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	scoreRows, err := db.conn.Query(
+		"SELECT chat_id, correct, answered FROM group_scores WHERE user_id = ? ORDER BY chat_id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer scoreRows.Close()
+
+	for scoreRows.Next() {
+		score := models.GroupScore{UserID: userID}
+		if err := scoreRows.Scan(&score.ChatID, &score.Correct, &score.Answered); err != nil {
+			return nil, err
+		}
+		export.GroupScores = append(export.GroupScores, score)
+	}
+	if err := scoreRows.Err(); err != nil {
+		return nil, err
+	}
+
+	export.LearnPosition, err = db.GetLearnPosition(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export.BlitzBest, err = db.GetBlitzBest(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	presentationRows, err := db.conn.Query(
+		"SELECT id, chat_id, question_number, created_at, answered_at FROM presentations WHERE user_id = ? ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer presentationRows.Close()
+
+	for presentationRows.Next() {
+		presentation := models.Presentation{UserID: userID}
+		if err := presentationRows.Scan(&presentation.ID, &presentation.ChatID, &presentation.QuestionNumber,
+			&presentation.CreatedAt, &presentation.AnsweredAt); err != nil {
+			return nil, err
+		}
+		export.Presentations = append(export.Presentations, presentation)
+	}
+	if err := presentationRows.Err(); err != nil {
+		return nil, err
+	}
+
+	export.Reports, err = db.getUserReports(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export.KnownQuestions, err = db.getUserKnownQuestions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// DeleteUserData erases every row stored about a user in a single transaction.
+// Broadcasts the user created as an admin are kept but no longer attributed to them.
+func (db *DB) DeleteUserData(userID int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"DELETE FROM user_activity WHERE user_id = ?",
+		"DELETE FROM user_activity_archive WHERE user_id = ?",
+		"DELETE FROM ai_usage WHERE user_id = ?",
+		"DELETE FROM broadcast_deliveries WHERE user_id = ?",
+		"DELETE FROM group_scores WHERE user_id = ?",
+		"DELETE FROM learn_progress WHERE user_id = ?",
+		"DELETE FROM blitz_best WHERE user_id = ?",
+		"DELETE FROM presentations WHERE user_id = ?",
+		"DELETE FROM known_questions WHERE user_id = ?",
+		"UPDATE broadcasts SET created_by = 0 WHERE created_by = ?",
+		"DELETE FROM reports WHERE user_id = ?",
+		"DELETE FROM users WHERE user_id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// TouchUser records that a user interacted with the bot, creating or updating their profile.
+// A zero ChatID keeps the private chat ID already stored for the user.
+func (db *DB) TouchUser(user models.User) error {
+	now := time.Now().Unix()
+	_, err := db.conn.Exec(`
+		INSERT INTO users (user_id, chat_id, username, first_name, last_name, language_code, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			chat_id = CASE WHEN excluded.chat_id != 0 THEN excluded.chat_id ELSE users.chat_id END,
+			username = excluded.username,
+			first_name = excluded.first_name,
+			last_name = excluded.last_name,
+			language_code = excluded.language_code,
+			first_seen = CASE WHEN users.first_seen = 0 THEN excluded.first_seen ELSE users.first_seen END,
+			last_seen = excluded.last_seen,
+			active = 1,
+			inactive_since = 0`,
+		user.UserID, user.ChatID, user.Username, user.FirstName, user.LastName, user.LanguageCode, now, now,
+	)
+	return err
+}
+
+// GetUser retrieves a user's profile, or nil if the user is unknown
+func (db *DB) GetUser(userID int64) (*models.User, error) {
+	var user models.User
+	err := db.conn.QueryRow(`
+		SELECT user_id, chat_id, username, first_name, last_name, language_code, first_seen, last_seen,
+			active, inactive_since, language, bundesland, reminder_time, presentation
+		FROM users WHERE user_id = ?`,
+		userID,
+	).Scan(&user.UserID, &user.ChatID, &user.Username, &user.FirstName, &user.LastName, &user.LanguageCode,
+		&user.FirstSeen, &user.LastSeen, &user.Active, &user.InactiveSince,
+		&user.Language, &user.Bundesland, &user.ReminderTime, &user.Presentation)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetUserLanguage sets the user's preferred language for explanations
+func (db *DB) SetUserLanguage(userID int64, language string) error {
+	_, err := db.conn.Exec("UPDATE users SET language = ? WHERE user_id = ?", language, userID)
+	return err
+}
+
+// SetUserBundesland sets the user's federal state
+func (db *DB) SetUserBundesland(userID int64, bundesland string) error {
+	_, err := db.conn.Exec("UPDATE users SET bundesland = ? WHERE user_id = ?", bundesland, userID)
+	return err
+}
+
+// SetUserReminderTime sets the user's daily reminder time (HH:MM), or disables it when empty
+func (db *DB) SetUserReminderTime(userID int64, reminderTime string) error {
+	_, err := db.conn.Exec("UPDATE users SET reminder_time = ? WHERE user_id = ?", reminderTime, userID)
+	return err
+}
+
+// SetUserPresentation sets how questions are shown to the user
+func (db *DB) SetUserPresentation(userID int64, presentation string) error {
+	_, err := db.conn.Exec("UPDATE users SET presentation = ? WHERE user_id = ?", presentation, userID)
+	return err
+}
+
+// SetUserActive marks a user as active or as inactive (e.g. after they blocked the bot)
+func (db *DB) SetUserActive(userID int64, active bool) error {
+	inactiveSince := int64(0)
+	if !active {
+		inactiveSince = time.Now().Unix()
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO users (user_id, active, inactive_since) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET active = excluded.active, inactive_since = excluded.inactive_since`,
+		userID, active, inactiveSince,
+	)
+	return err
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/korjavin/lebentestbot/catalogue"
+	"github.com/korjavin/lebentestbot/config"
+	"github.com/korjavin/lebentestbot/models"
+)
+
+const (
+	cmdImportCatalogue = "import-catalogue"
+
+	diffQuestionRunes = 70 // Characters of the question text shown per change
+)
+
+// runImportCatalogue imports the official catalogue from the text extracted from its PDF,
+// prints how it differs from the current catalogue and, with -write, replaces it.
+// It returns the exit code.
+func runImportCatalogue(args []string) int {
+	flags := flag.NewFlagSet(cmdImportCatalogue, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lebentestbot %s -text <file> [flags]\n\n", cmdImportCatalogue)
+		fmt.Fprintln(flags.Output(), "Imports the official catalogue PDF, extracted with:")
+		fmt.Fprintln(flags.Output(), "  pdftotext gesamtfragenkatalog.pdf catalogue.txt")
+		fmt.Fprintln(flags.Output(), "  pdfimages -p -png gesamtfragenkatalog.pdf images/img")
+		fmt.Fprintln(flags.Output(), "and prints how it differs from the current catalogue.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	textPath := flags.String("text", "", "text of the catalogue PDF, extracted with pdftotext (required)")
+	imagesDir := flags.String("images", "", "directory of the images of the catalogue PDF, extracted with pdfimages -p -png")
+	assetsDir := flags.String("assets", config.AssetsDirFromEnv(), "directory of the current catalogue")
+	version := flags.String("version", time.Now().Format("2006-01-02"), "version of the imported catalogue, e.g. the date of the PDF")
+	write := flags.Bool("write", false, "replace the catalogue and its images in the assets directory")
+	if err := flags.Parse(args); err != nil {
+		return exitFailure
+	}
+	if *textPath == "" {
+		flags.Usage()
+		return exitFailure
+	}
+
+	text, err := os.ReadFile(*textPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+		return exitFailure
+	}
+
+	imp := catalogue.ParseText(string(text))
+	if *imagesDir != "" {
+		if err := imp.LinkImages(*imagesDir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+			return exitFailure
+		}
+	}
+
+	currentNationwide, err := readCatalogueFile(*assetsDir, catalogue.QuestionsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+		return exitFailure
+	}
+	currentStates, err := readCatalogueFile(*assetsDir, catalogue.StatesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+		return exitFailure
+	}
+	imp.CarryOver(currentNationwide.Questions, currentStates.Questions)
+
+	nationwide := &catalogue.File{Version: *version, Questions: imp.Nationwide()}
+	states := &catalogue.File{Version: *version, Questions: imp.States()}
+	printCatalogueDiff(os.Stdout, catalogue.QuestionsFile, currentNationwide, nationwide)
+	printCatalogueDiff(os.Stdout, catalogue.StatesFile, currentStates, states)
+
+	for _, note := range imp.Notes {
+		fmt.Fprintf(os.Stderr, "note: %s\n", note)
+	}
+
+	files := map[string]*catalogue.File{catalogue.QuestionsFile: nationwide, catalogue.StatesFile: states}
+	encoded := make(map[string][]byte, len(files))
+	valid := true
+	for name, file := range files {
+		data, err := encodeCatalogue(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+			return exitFailure
+		}
+		encoded[name] = data
+
+		// Check the result the way the bot does when loading it
+		_, issues := catalogue.Parse(data, *assetsDir)
+		for _, issue := range issues {
+			if issue.Severity == catalogue.SeverityError {
+				fmt.Fprintf(os.Stderr, "error: %s: %s\n", name, issue)
+				valid = false
+			}
+		}
+	}
+	if !valid {
+		fmt.Fprintf(os.Stderr, "%s: the imported catalogue is invalid and was not written\n", cmdImportCatalogue)
+		return exitInvalid
+	}
+
+	if !*write {
+		fmt.Fprintf(os.Stderr, "%s: nothing was written, use -write to replace the catalogue\n", cmdImportCatalogue)
+		return exitValid
+	}
+
+	// The images go first, so that the bot finds them when it reloads the new catalogue
+	images, err := imp.WriteImages(*assetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+		return exitFailure
+	}
+	for _, name := range []string{catalogue.StatesFile, catalogue.QuestionsFile} {
+		if err := writeFileAtomically(filepath.Join(*assetsDir, name), encoded[name]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmdImportCatalogue, err)
+			return exitFailure
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: wrote %s, %s and %d images to %s\n",
+		cmdImportCatalogue, catalogue.QuestionsFile, catalogue.StatesFile, images, *assetsDir)
+	return exitValid
+}
+
+// readCatalogueFile reads a catalogue file, skipping placeholder entries.
+// A missing file has no questions.
+func readCatalogueFile(dir, name string) (*catalogue.File, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return &catalogue.File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := catalogue.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var questions []models.Question
+	seen := make(map[int]bool)
+	unique := true
+	for _, q := range file.Questions {
+		if q.Number == -1 {
+			continue
+		}
+		unique = unique && !seen[q.Number]
+		seen[q.Number] = true
+		questions = append(questions, q)
+	}
+
+	// Before the importer, the state questions were numbered from 1 to 10 within each
+	// state; number them through in file order like the importer does
+	if !unique {
+		for i := range questions {
+			if questions[i].ID == catalogue.DefaultID(questions[i].Number) {
+				questions[i].ID = catalogue.DefaultID(i + 1)
+			}
+			questions[i].Number = i + 1
+		}
+	}
+	file.Questions = questions
+	return file, nil
+}
+
+// printCatalogueDiff prints the changes between the current and the imported version of a catalogue file
+func printCatalogueDiff(w io.Writer, name string, current, imported *catalogue.File) {
+	changes := catalogue.Diff(current.Questions, imported.Questions)
+	moves := catalogue.Moves(current.Questions, imported.Questions)
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	fmt.Fprintf(w, "%s (version %q, was %q): %d questions imported, %d added, %d removed, %d modified, %d renumbered\n",
+		name, imported.Version, current.Version, len(imported.Questions),
+		counts[catalogue.ChangeAdded], counts[catalogue.ChangeRemoved], counts[catalogue.ChangeModified], len(moves))
+
+	for _, change := range changes {
+		question := shortenQuestion(change.Question)
+		switch change.Kind {
+		case catalogue.ChangeAdded:
+			fmt.Fprintf(w, "  + #%d %s\n", change.Number, question)
+		case catalogue.ChangeRemoved:
+			fmt.Fprintf(w, "  - #%d %s\n", change.Number, question)
+		default:
+			fmt.Fprintf(w, "  ~ #%d %v: %s\n", change.Number, change.Fields, question)
+		}
+	}
+	for _, move := range moves {
+		fmt.Fprintf(w, "  > #%d is now #%d: %s\n", move.From, move.To, shortenQuestion(move.Question))
+	}
+}
+
+// shortenQuestion cuts the text of a question to the length shown in the diff
+func shortenQuestion(text string) string {
+	question := []rune(text)
+	if len(question) > diffQuestionRunes {
+		question = append(question[:diffQuestionRunes-1], '…')
+	}
+	return string(question)
+}
+
+// encodeCatalogue encodes a catalogue file the way the catalogue files are formatted
+func encodeCatalogue(file *catalogue.File) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFileAtomically replaces a file, so that the bot never reloads a half-written catalogue
+func writeFileAtomically(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
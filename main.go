@@ -9,6 +9,18 @@ import (
 )
 
 func main() {
+	// Maintenance commands run without the bot configuration
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case cmdValidateCatalogue:
+			os.Exit(runValidateCatalogue(os.Args[2:]))
+		case cmdImportCatalogue:
+			os.Exit(runImportCatalogue(os.Args[2:]))
+		case cmdUsageReport:
+			os.Exit(runUsageReport(os.Args[2:]))
+		}
+	}
+
 	// Configure logging
 	log.SetOutput(os.Stdout)
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
@@ -0,0 +1,10 @@
+package models
+
+// BlitzBest is a user's personal best in the /blitz speed round
+type BlitzBest struct {
+	UserID     int64
+	Score      int   // Right answers in the best round
+	Questions  int   // Questions in the best round
+	Rounds     int   // Rounds played in total
+	AchievedAt int64 // Unix time of the best round
+}
@@ -0,0 +1,50 @@
+package models
+
+// Broadcast statuses
+const (
+	BroadcastRunning   = "running"
+	BroadcastPaused    = "paused"
+	BroadcastDone      = "done"
+	BroadcastCancelled = "cancelled"
+)
+
+// Delivery statuses of a broadcast to a single recipient
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliveryBlocked = "blocked"
+)
+
+// Broadcast is an announcement queued for delivery to all active users
+type Broadcast struct {
+	ID         int64
+	Text       string
+	Status     string
+	CreatedBy  int64
+	CreatedAt  int64
+	FinishedAt int64
+}
+
+// BroadcastProgress counts the deliveries of a broadcast by status
+type BroadcastProgress struct {
+	Pending int
+	Sent    int
+	Failed  int
+	Blocked int
+}
+
+// BroadcastRecipient is a user a broadcast is delivered to
+type BroadcastRecipient struct {
+	UserID int64
+	ChatID int64
+}
+
+// BroadcastDelivery is the delivery status of a broadcast to a single user
+type BroadcastDelivery struct {
+	BroadcastID int64
+	UserID      int64
+	Status      string
+	Error       string
+	UpdatedAt   int64
+}
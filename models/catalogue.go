@@ -0,0 +1,20 @@
+package models
+
+// CatalogueMigration records how the stored data was brought in line with a new version of the catalogue
+type CatalogueMigration struct {
+	ID          int64
+	FromVersion string
+	ToVersion   string
+	Renumbered  int   // Questions whose data was moved to their new number
+	Removed     int   // Questions no longer in the catalogue
+	Archived    int64 // Answers and explanations of removed questions moved to the archive
+	AppliedAt   int64
+}
+
+// ArchivedActivity is an answer to a question that was removed from the catalogue
+type ArchivedActivity struct {
+	UserActivity
+	QuestionID       string
+	CatalogueVersion string // Version of the catalogue the question was answered in
+	ArchivedAt       int64
+}
@@ -0,0 +1,34 @@
+package models
+
+// Reasons a user can give when reporting a problem with a question
+const (
+	ReportWrongAnswer    = "wrong_answer"
+	ReportBadTranslation = "bad_translation"
+	ReportMissingImage   = "missing_image"
+	ReportTypo           = "typo"
+)
+
+// Moderation statuses of a report
+const (
+	ReportOpen     = "open"
+	ReportResolved = "resolved"
+)
+
+// Report is a problem with a question reported by a user
+type Report struct {
+	ID             int64
+	UserID         int64
+	QuestionNumber int
+	Reason         string
+	Comment        string
+	Status         string
+	ResolvedBy     int64 // Admin who resolved the report
+	ResolvedAt     int64
+	CreatedAt      int64
+}
+
+// KnownQuestion is a question a user marked as known, so it isn't asked anymore
+type KnownQuestion struct {
+	QuestionNumber int
+	MarkedAt       int64
+}
@@ -0,0 +1,11 @@
+package models
+
+// GroupScore is a member's result in a group's quiz leaderboard
+type GroupScore struct {
+	ChatID    int64
+	UserID    int64
+	Username  string
+	FirstName string
+	Correct   int
+	Answered  int
+}
@@ -0,0 +1,12 @@
+package models
+
+// Presentation is a question shown to a user with answer buttons. Each presentation
+// accepts a single answer.
+type Presentation struct {
+	ID             int64
+	UserID         int64
+	ChatID         int64
+	QuestionNumber int
+	CreatedAt      int64
+	AnsweredAt     int64 // 0 while unanswered
+}
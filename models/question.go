@@ -2,14 +2,30 @@ package models
 
 // Question represents a question from the questions.json file
 type Question struct {
+	ID          string   `json:"ID,omitempty"` // Stays the same when the question is renumbered
 	Number      int      `json:"Number"`
 	Question    string   `json:"Question"`
 	Answers     []string `json:"Answers"`
 	RightAnswer int      `json:"Right answer"`
 	Category    string   `json:"Category"`
+	Topic       string   `json:"Topic,omitempty"` // Subject area shown in the statistics, e.g. "Geschichte"
 	Image       string   `json:"Image,omitempty"`
 }
 
+// Kinds of user activity
+const (
+	ActivityQuiz      = "quiz"      // A multiple-choice answer
+	ActivityFlashcard = "flashcard" // A flashcard self-assessment; AnswerNumber holds the rating
+)
+
+// Flashcard self-assessment ratings, from not knowing the answer to knowing it well
+const (
+	FlashcardAgain = iota
+	FlashcardHard
+	FlashcardGood
+	FlashcardEasy
+)
+
 // UserActivity stores user interaction with questions
 type UserActivity struct {
 	UserID         int64
@@ -17,6 +33,7 @@ type UserActivity struct {
 	AnswerNumber   int
 	Correct        bool
 	Timestamp      int64
+	Kind           string
 }
 
 // DeepseekCache stores cached responses from the Deepseek API
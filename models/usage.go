@@ -0,0 +1,21 @@
+package models
+
+// AIUsage records the tokens and cost of a single paid AI call
+type AIUsage struct {
+	UserID           int64
+	QuestionNumber   int
+	Kind             string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Timestamp        int64
+}
+
+// AIUsageSummary aggregates AI usage per day or per user
+type AIUsageSummary struct {
+	Day    string // YYYY-MM-DD, set for per-day summaries
+	UserID int64  // Set for per-user summaries
+	Calls  int
+	Tokens int
+	Cost   float64
+}
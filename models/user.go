@@ -0,0 +1,59 @@
+package models
+
+import "strings"
+
+// User is a Telegram user's profile, lifecycle and preferences
+type User struct {
+	UserID        int64
+	ChatID        int64 // Private chat with the bot, 0 if the user never wrote to it privately
+	Username      string
+	FirstName     string
+	LastName      string
+	LanguageCode  string // Language reported by the Telegram client
+	FirstSeen     int64
+	LastSeen      int64
+	Active        bool // False once the user blocked the bot
+	InactiveSince int64
+
+	// Preferences
+	Language     string // Preferred language for explanations, empty to use LanguageCode
+	Bundesland   string // Full name of the user's federal state
+	ReminderTime string // Daily reminder time as HH:MM, empty if disabled
+	Presentation string // How questions are shown: "buttons" (default) or "poll"
+}
+
+// State is a German federal state (Bundesland)
+type State struct {
+	Code string
+	Name string
+}
+
+// States lists the 16 German federal states
+var States = []State{
+	{"BW", "Baden-Württemberg"},
+	{"BY", "Bayern"},
+	{"BE", "Berlin"},
+	{"BB", "Brandenburg"},
+	{"HB", "Bremen"},
+	{"HH", "Hamburg"},
+	{"HE", "Hessen"},
+	{"MV", "Mecklenburg-Vorpommern"},
+	{"NI", "Niedersachsen"},
+	{"NW", "Nordrhein-Westfalen"},
+	{"RP", "Rheinland-Pfalz"},
+	{"SL", "Saarland"},
+	{"SN", "Sachsen"},
+	{"ST", "Sachsen-Anhalt"},
+	{"SH", "Schleswig-Holstein"},
+	{"TH", "Thüringen"},
+}
+
+// FindState looks up a federal state by its code or name, ignoring case
+func FindState(codeOrName string) (State, bool) {
+	for _, state := range States {
+		if strings.EqualFold(state.Code, codeOrName) || strings.EqualFold(state.Name, codeOrName) {
+			return state, true
+		}
+	}
+	return State{}, false
+}
@@ -0,0 +1,17 @@
+package models
+
+// UserDataExport holds everything stored about a user, as sent by /mydata
+type UserDataExport struct {
+	ExportedAt          int64
+	Profile             *User
+	Activity            []UserActivity
+	ArchivedActivity    []ArchivedActivity // Answers to questions removed from the catalogue
+	AIUsage             []AIUsage
+	BroadcastDeliveries []BroadcastDelivery
+	GroupScores         []GroupScore
+	LearnPosition       int // Question number reached in /learn, 0 if never used
+	BlitzBest           *BlitzBest
+	Presentations       []Presentation
+	Reports             []Report
+	KnownQuestions      []KnownQuestion
+}
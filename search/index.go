@@ -0,0 +1,260 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+// Weights of a token depending on where it occurs in a question
+const (
+	questionWeight = 2.0
+	answerWeight   = 1.0
+	extraWeight    = 0.5 // Cached translations and explanations
+)
+
+// BM25 ranking parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// umlautFolder spells umlauts and ß the way they are typed without a German keyboard
+var umlautFolder = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss")
+
+// vowelFolder removes the difference between "ae" and "a" and so on, so that
+// "fur", "fuer" and "für" all match each other
+var vowelFolder = strings.NewReplacer("ae", "a", "oe", "o", "ue", "u")
+
+// document is an indexed question
+type document struct {
+	question models.Question
+	terms    map[string]float64 // Weighted term frequencies of the question text and answers
+	extra    map[string]float64 // Weighted term frequencies of the extra text
+}
+
+// Index is an in-memory full-text index over the question catalogue.
+// It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     []*document
+	byNumber map[int]int                 // Question number -> document index
+	postings map[string]map[int]struct{} // Term -> documents containing it
+	terms    []string                    // All indexed terms, sorted, for prefix lookups
+}
+
+// NewIndex builds an index over the question text and answers, plus any extra
+// text (such as cached translations) keyed by question number
+func NewIndex(questions []models.Question, extra map[int]string) *Index {
+	idx := &Index{}
+	idx.Rebuild(questions, extra)
+	return idx
+}
+
+// Rebuild replaces the indexed questions, e.g. after the catalogue was reloaded
+func (idx *Index) Rebuild(questions []models.Question, extra map[int]string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = nil
+	idx.byNumber = make(map[int]int)
+
+	for i, q := range questions {
+		doc := &document{question: q, terms: make(map[string]float64)}
+		addTerms(doc.terms, q.Question, questionWeight)
+		for _, answer := range q.Answers {
+			addTerms(doc.terms, answer, answerWeight)
+		}
+		if text, ok := extra[q.Number]; ok {
+			doc.extra = make(map[string]float64)
+			addTerms(doc.extra, text, extraWeight)
+		}
+
+		idx.docs = append(idx.docs, doc)
+		idx.byNumber[q.Number] = i
+	}
+
+	idx.rebuildPostings()
+}
+
+// SetExtraText replaces the extra text indexed for a question, e.g. after its explanation was cached
+func (idx *Index) SetExtraText(questionNumber int, text string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	i, ok := idx.byNumber[questionNumber]
+	if !ok {
+		return
+	}
+
+	idx.docs[i].extra = make(map[string]float64)
+	addTerms(idx.docs[i].extra, text, extraWeight)
+	idx.rebuildPostings()
+}
+
+// rebuildPostings recomputes the postings and sorted term list. The caller must hold the write lock.
+func (idx *Index) rebuildPostings() {
+	idx.postings = make(map[string]map[int]struct{})
+	for i, doc := range idx.docs {
+		for _, terms := range []map[string]float64{doc.terms, doc.extra} {
+			for term := range terms {
+				if idx.postings[term] == nil {
+					idx.postings[term] = make(map[int]struct{})
+				}
+				idx.postings[term][i] = struct{}{}
+			}
+		}
+	}
+
+	idx.terms = idx.terms[:0]
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+}
+
+// addTerms adds the normalized words of text to a term frequency map
+func addTerms(terms map[string]float64, text string, weight float64) {
+	for _, word := range Tokenize(text) {
+		terms[word] += weight
+	}
+}
+
+// Search returns up to limit questions matching the query, best matches first.
+// Questions matching more of the query's words rank higher, then by BM25 score.
+// The last word may be incomplete, as the user may still be typing it.
+// A query that is a question number returns that question.
+func (idx *Index) Search(query string, limit int) []models.Question {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	query = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(query), "#"))
+
+	if number, err := strconv.Atoi(query); err == nil {
+		if i, ok := idx.byNumber[number]; ok {
+			return []models.Question{idx.docs[i].question}
+		}
+		return nil
+	}
+
+	words := Tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	avgLength := idx.averageLength()
+	matched := make(map[int]int)
+	scores := make(map[int]float64)
+
+	for n, word := range words {
+		terms := []string{word}
+		if n == len(words)-1 {
+			terms = idx.withPrefix(word)
+		}
+
+		// Score the best matching term of each document for this word
+		best := make(map[int]float64)
+		for _, term := range terms {
+			idf := idx.idf(term)
+			for i := range idx.postings[term] {
+				doc := idx.docs[i]
+				tf := doc.terms[term] + doc.extra[term]
+				score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*doc.length()/avgLength))
+				if score > best[i] {
+					best[i] = score
+				}
+			}
+		}
+
+		for i, score := range best {
+			matched[i]++
+			scores[i] += score
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for i := range scores {
+		ranked = append(ranked, i)
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		da, db := ranked[a], ranked[b]
+		if matched[da] != matched[db] {
+			return matched[da] > matched[db]
+		}
+		if scores[da] != scores[db] {
+			return scores[da] > scores[db]
+		}
+		return idx.docs[da].question.Number < idx.docs[db].question.Number
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	result := make([]models.Question, len(ranked))
+	for i, di := range ranked {
+		result[i] = idx.docs[di].question
+	}
+	return result
+}
+
+// withPrefix returns all indexed terms starting with prefix
+func (idx *Index) withPrefix(prefix string) []string {
+	var terms []string
+	start := sort.SearchStrings(idx.terms, prefix)
+	for _, term := range idx.terms[start:] {
+		if !strings.HasPrefix(term, prefix) {
+			break
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// idf returns the inverse document frequency of a term
+func (idx *Index) idf(term string) float64 {
+	n := float64(len(idx.docs))
+	df := float64(len(idx.postings[term]))
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// averageLength returns the average weighted length of the indexed documents
+func (idx *Index) averageLength() float64 {
+	if len(idx.docs) == 0 {
+		return 1
+	}
+	total := 0.0
+	for _, doc := range idx.docs {
+		total += doc.length()
+	}
+	return math.Max(total/float64(len(idx.docs)), 1)
+}
+
+// length returns the weighted number of words in the document
+func (d *document) length() float64 {
+	total := 0.0
+	for _, tf := range d.terms {
+		total += tf
+	}
+	for _, tf := range d.extra {
+		total += tf
+	}
+	return total
+}
+
+// Tokenize splits text into normalized words: lowercase, with umlauts and ß
+// folded so that "Bürger", "Buerger" and "Burger" are the same word
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = vowelFolder.Replace(umlautFolder.Replace(word))
+	}
+	return words
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/korjavin/lebentestbot/models"
+)
+
+var testQuestions = []models.Question{
+	{Number: 1, Question: "Welche Rechte haben Bürger in Deutschland?", Answers: []string{"Wahlrecht", "keine"}},
+	{Number: 2, Question: "Wer wählt den Bundeskanzler?", Answers: []string{"der Bundestag", "das Volk"}},
+	{Number: 3, Question: "Was ist die Hauptstadt von Deutschland?", Answers: []string{"Berlin", "Bonn"}},
+	{Number: 4, Question: "Was bedeutet Demokratie?", Answers: []string{"Herrschaft des Volkes", "Herrschaft des Königs"}},
+	{Number: 5, Question: "Wer ist Staatsoberhaupt?", Answers: []string{"der Bundespräsident", "der Bundeskanzler"}},
+}
+
+// numbers returns the numbers of the questions found
+func numbers(questions []models.Question) []int {
+	result := make([]int, len(questions))
+	for i, q := range questions {
+		result[i] = q.Number
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Bürger", []string{"burger"}},
+		{"Buerger", []string{"burger"}},
+		{"Burger", []string{"burger"}},
+		{"Straße, Größe!", []string{"strasse", "grosse"}},
+		{"Art. 20 GG", []string{"art", "20", "gg"}},
+	}
+
+	for _, tt := range tests {
+		got := Tokenize(tt.text)
+		if len(got) != len(tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchFoldsUmlauts(t *testing.T) {
+	idx := NewIndex(testQuestions, nil)
+	for _, query := range []string{"Bürger", "Buerger", "burger", "waehlt", "wahlt"} {
+		got := numbers(idx.Search(query, 10))
+		if len(got) == 0 {
+			t.Errorf("Search(%q) found nothing", query)
+		}
+	}
+	if got := numbers(idx.Search("Buerger", 10)); len(got) != 1 || got[0] != 1 {
+		t.Errorf("Search(Buerger) = %v, want [1]", got)
+	}
+}
+
+func TestSearchPrefixOfLastWord(t *testing.T) {
+	idx := NewIndex(testQuestions, nil)
+
+	if got := numbers(idx.Search("Hauptst", 10)); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Search(Hauptst) = %v, want [3]", got)
+	}
+	// Only the last word may be incomplete
+	for _, number := range numbers(idx.Search("Hauptst Volk", 10)) {
+		if number == 3 {
+			t.Errorf("Search(Hauptst Volk) found 3 by the incomplete first word")
+		}
+	}
+	if got := numbers(idx.Search("Deutschland Haupt", 10)); len(got) == 0 || got[0] != 3 {
+		t.Errorf("Search(Deutschland Haupt) = %v, want 3 first", got)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := NewIndex(testQuestions, nil)
+
+	// Both questions mention Bundeskanzler, but only #2 has it in the question text
+	got := numbers(idx.Search("Bundeskanzler", 10))
+	if len(got) != 2 || got[0] != 2 || got[1] != 5 {
+		t.Errorf("Search(Bundeskanzler) = %v, want [2 5]", got)
+	}
+
+	// A question matching more of the words ranks first
+	got = numbers(idx.Search("Herrschaft Volk", 10))
+	if len(got) != 2 || got[0] != 4 || got[1] != 2 {
+		t.Errorf("Search(Herrschaft Volk) = %v, want [4 2]", got)
+	}
+
+	// A rarer word weighs more than a common one
+	got = numbers(idx.Search("Deutschland Rechte", 10))
+	if len(got) != 2 || got[0] != 1 {
+		t.Errorf("Search(Deutschland Rechte) = %v, want 1 first", got)
+	}
+}
+
+func TestSearchNumberAndLimit(t *testing.T) {
+	idx := NewIndex(testQuestions, nil)
+
+	if got := numbers(idx.Search("#4", 10)); len(got) != 1 || got[0] != 4 {
+		t.Errorf("Search(#4) = %v, want [4]", got)
+	}
+	if got := idx.Search("99", 10); len(got) != 0 {
+		t.Errorf("Search(99) = %v, want nothing", numbers(got))
+	}
+	if got := idx.Search("der", 1); len(got) != 1 {
+		t.Errorf("Search(der) with limit 1 returned %d questions", len(got))
+	}
+}
+
+func TestSetExtraText(t *testing.T) {
+	idx := NewIndex(testQuestions, map[int]string{3: "capital"})
+
+	if got := numbers(idx.Search("capital", 10)); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Search(capital) = %v, want [3]", got)
+	}
+
+	idx.SetExtraText(4, "democracy means rule of the people")
+	if got := numbers(idx.Search("democracy", 10)); len(got) != 1 || got[0] != 4 {
+		t.Errorf("Search(democracy) = %v, want [4]", got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/korjavin/lebentestbot/bot"
+	"github.com/korjavin/lebentestbot/config"
+	"github.com/korjavin/lebentestbot/database"
+)
+
+const cmdUsageReport = "usage-report"
+
+// runUsageReport prints the AI spend per day and per user recorded in the database and
+// returns the exit code
+func runUsageReport(args []string) int {
+	flags := flag.NewFlagSet(cmdUsageReport, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lebentestbot %s [flags]\n\n", cmdUsageReport)
+		fmt.Fprintln(flags.Output(), "Prints the AI spend per day and of the top users over the last days.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", config.DatabasePathFromEnv(), "path of the SQLite database")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	db, err := database.New(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	report, err := bot.UsageReport(db, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading AI usage: %v\n", err)
+		return 1
+	}
+	fmt.Println(report)
+	return 0
+}
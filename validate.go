@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/korjavin/lebentestbot/catalogue"
+	"github.com/korjavin/lebentestbot/config"
+)
+
+const cmdValidateCatalogue = "validate-catalogue"
+
+// Exit codes of the maintenance commands
+const (
+	exitValid   = 0
+	exitInvalid = 1 // The catalogue has issues
+	exitFailure = 2 // The command couldn't run, e.g. the catalogue can't be read
+)
+
+// runValidateCatalogue checks the question catalogue, prints all issues as a JSON array
+// to stdout and returns the exit code
+func runValidateCatalogue(args []string) int {
+	flags := flag.NewFlagSet(cmdValidateCatalogue, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lebentestbot %s [flags]\n\n", cmdValidateCatalogue)
+		fmt.Fprintln(flags.Output(), "Checks the question catalogue and prints the issues found as a JSON array.")
+		fmt.Fprintln(flags.Output(), "Exits with 1 if there are issues and 2 if the catalogue can't be read.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	assetsDir := flags.String("assets", config.AssetsDirFromEnv(), "directory of "+catalogue.QuestionsFile+" and the images")
+	allowWarnings := flags.Bool("allow-warnings", false, "exit with 0 if there are only warnings")
+	if err := flags.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	issues, err := catalogue.Validate(*assetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdValidateCatalogue, err)
+		return exitFailure
+	}
+	if issues == nil {
+		// Print an empty list rather than null
+		issues = []catalogue.Issue{}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(issues); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdValidateCatalogue, err)
+		return exitFailure
+	}
+
+	var errors, warnings int
+	for _, issue := range issues {
+		if issue.Severity == catalogue.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d errors, %d warnings\n", cmdValidateCatalogue, errors, warnings)
+
+	if errors > 0 || (warnings > 0 && !*allowWarnings) {
+		return exitInvalid
+	}
+	return exitValid
+}